@@ -2,28 +2,52 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
 	"sync"
 )
 
+// Usage:
+// cat subdomains.txt | cleansub -t target.com
+// extract every hostname from JS bundles, JSON dumps or HTML
+// cat bundle.js | cleansub -a -t target.com
+
 var nameStripRE = regexp.MustCompile(`^u[0-9a-f]{4}|20|22|25|2b|2f|3d|3a|40`)
 var subdomainRE = regexp.MustCompile(`(([a-zA-Z0-9]{1}|[_a-zA-Z0-9]{1}[_a-zA-Z0-9-]{0,61}[a-zA-Z0-9]{1})[.]{1})+[a-zA-Z]{2,61}`)
 var subwithIPv4 = regexp.MustCompile(`(?m)[0-9]{1,3}\.[0-9]{1,3}\.[0-9]{1,3}\.`)
 var subwithIPv42 = regexp.MustCompile(`(?m)[0-9]{1,3}\-[0-9]{1,3}\-[0-9]{1,3}`)
 
+// escaped dots commonly seen in JS, JSON and HTML sources
+var dotReplacer = strings.NewReplacer(
+	`\u002e`, ".", `\u002E`, ".",
+	`\x2e`, ".", `\x2E`, ".",
+	"%252e", ".", "%252E", ".",
+	"%2e", ".", "%2E", ".",
+	"&#46;", ".", "&#x2e;", ".", "&#x2E;", ".",
+)
+
+// size of each chunk read in extract all mode
+const chunkSize = 1024 * 1024
+
 var (
 	target string
 
+	extractAll  bool
 	concurrency int
+
+	seen   = make(map[string]bool)
+	seenMu sync.Mutex
 )
 
 func main() {
 	flag.StringVar(&target, "t", "", "Specify target to clean")
 	flag.IntVar(&concurrency, "c", 20, "Set the concurrency level")
+	flag.BoolVar(&extractAll, "a", false, "Extract all hostnames from arbitrary text (JS, JSON, HTML) instead of one per line")
 
 	flag.Parse()
 
@@ -35,11 +59,24 @@ func main() {
 		go func() {
 			defer wg.Done()
 			for job := range jobs {
+				if extractAll {
+					checkCleanAll(job)
+					continue
+				}
 				checkClean(job)
 			}
 		}()
 	}
 
+	if extractAll {
+		go func() {
+			readChunks(os.Stdin, jobs)
+			close(jobs)
+		}()
+		wg.Wait()
+		return
+	}
+
 	sc := bufio.NewScanner(os.Stdin)
 	go func() {
 		for sc.Scan() {
@@ -54,7 +91,34 @@ func main() {
 }
 
 func checkClean(line string) {
-	name := subdomainRE.FindString(line)
+	name := cleanName(subdomainRE.FindString(line))
+	if name == "" {
+		return
+	}
+	fmt.Println(name)
+}
+
+// checkCleanAll print every unique hostname found in a chunk of text
+func checkCleanAll(chunk string) {
+	chunk = dotReplacer.Replace(chunk)
+	for _, match := range subdomainRE.FindAllString(chunk, -1) {
+		name := cleanName(match)
+		if name == "" {
+			continue
+		}
+
+		seenMu.Lock()
+		exist := seen[name]
+		seen[name] = true
+		seenMu.Unlock()
+		if !exist {
+			fmt.Println(name)
+		}
+	}
+}
+
+// cleanName normalize a matched hostname, return empty string if it should be dropped
+func cleanName(name string) string {
 	name = strings.ToLower(name)
 	for {
 		name = strings.Trim(name, "-.")
@@ -69,16 +133,51 @@ func checkClean(line string) {
 	if target != "" {
 		// only accept sub.target.com and target.com
 		if !strings.Contains(name, "."+target) && (name != target) {
-			return
+			return ""
 		}
 	}
 
 	isWildCard := removeWildcard(name)
 	if isWildCard {
-		return
+		return ""
 	}
 
-	fmt.Println(name)
+	return name
+}
+
+// readChunks split the input into chunks without the line length limit of bufio.Scanner
+// each chunk is cut at a byte that can't be part of a hostname so no name is split in half
+func readChunks(r io.Reader, jobs chan<- string) {
+	reader := bufio.NewReaderSize(r, chunkSize)
+	buf := make([]byte, chunkSize)
+	var carry []byte
+	for {
+		n, err := io.ReadFull(reader, buf)
+		data := append(carry, buf[:n]...)
+		carry = nil
+
+		if err == nil {
+			if i := bytes.LastIndexFunc(data, isBoundary); i >= 0 {
+				carry = append([]byte{}, data[i+1:]...)
+				data = data[:i+1]
+			}
+		}
+		if len(data) > 0 {
+			jobs <- string(data)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// isBoundary check if the character can't appear in a hostname or an escaped dot
+func isBoundary(r rune) bool {
+	switch r {
+	case ' ', '\t', '\r', '\n', '"', '\'', '`', '<', '>', '(', ')', '[', ']', '{', '}', ',', '/', '=', '|':
+		return true
+	}
+	return false
 }
 
 func removeWildcard(s string) bool {
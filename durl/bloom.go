@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// BloomFilter is a classic bloom filter with fixed capacity
+type BloomFilter struct {
+	bits     []uint64
+	m        uint64
+	k        uint64
+	count    int
+	capacity int
+}
+
+// NewBloomFilter create filter that hold capacity items with false positive rate fp
+func NewBloomFilter(capacity int, fp float64) *BloomFilter {
+	m := uint64(math.Ceil(-float64(capacity) * math.Log(fp) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Ceil(math.Ln2 * float64(m) / float64(capacity)))
+	if k < 1 {
+		k = 1
+	}
+	return &BloomFilter{
+		bits:     make([]uint64, (m+63)/64),
+		m:        m,
+		k:        k,
+		capacity: capacity,
+	}
+}
+
+// locations use enhanced double hashing on a SHA1 digest
+// so the locations are still well spread in small filters
+func (b *BloomFilter) locations(digest []byte) []uint64 {
+	h1 := binary.BigEndian.Uint64(digest[0:8]) % b.m
+	h2 := binary.BigEndian.Uint64(digest[8:16]) % b.m
+	locs := make([]uint64, b.k)
+	for i := uint64(0); i < b.k; i++ {
+		locs[i] = h1
+		h1 = (h1 + h2) % b.m
+		h2 = (h2 + i + 1) % b.m
+	}
+	return locs
+}
+
+// Test check if digest might be in the filter
+func (b *BloomFilter) Test(digest []byte) bool {
+	for _, loc := range b.locations(digest) {
+		if b.bits[loc/64]&(1<<(loc%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Add add digest to the filter
+func (b *BloomFilter) Add(digest []byte) {
+	for _, loc := range b.locations(digest) {
+		b.bits[loc/64] |= 1 << (loc % 64)
+	}
+	b.count++
+}
+
+// ScalableBloomFilter add a new and bigger filter when the current one is full
+// so the false positive rate stay under the limit without knowing the input size
+type ScalableBloomFilter struct {
+	filters  []*BloomFilter
+	fp       float64
+	capacity int
+}
+
+const (
+	// each new filter is bigger than the previous one
+	bloomGrowth = 2
+	// each new filter have tighter false positive rate
+	bloomTightening = 0.8
+)
+
+// ValidateBloom check the capacity and false positive rate before creating the filter
+func ValidateBloom(capacity int, fp float64) error {
+	if capacity <= 0 {
+		return fmt.Errorf("bloom size must be greater than 0, got %d", capacity)
+	}
+	if fp <= 0 || fp >= 1 {
+		return fmt.Errorf("false positive rate must be between 0 and 1, got %v", fp)
+	}
+	return nil
+}
+
+// NewScalableBloomFilter create filter with initial capacity and the total false positive rate fp
+func NewScalableBloomFilter(capacity int, fp float64) *ScalableBloomFilter {
+	s := &ScalableBloomFilter{
+		fp:       fp,
+		capacity: capacity,
+	}
+	s.grow()
+	return s
+}
+
+func (s *ScalableBloomFilter) grow() {
+	capacity := s.capacity * int(math.Pow(bloomGrowth, float64(len(s.filters))))
+	fp := s.fp * (1 - bloomTightening) * math.Pow(bloomTightening, float64(len(s.filters)))
+	s.filters = append(s.filters, NewBloomFilter(capacity, fp))
+}
+
+// TestAndAdd return true if digest might be seen before, otherwise add it
+func (s *ScalableBloomFilter) TestAndAdd(digest []byte) bool {
+	for _, f := range s.filters {
+		if f.Test(digest) {
+			return true
+		}
+	}
+
+	current := s.filters[len(s.filters)-1]
+	if current.count >= current.capacity {
+		s.grow()
+		current = s.filters[len(s.filters)-1]
+	}
+	current.Add(digest)
+	return false
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"testing"
+)
+
+func TestScalableBloomFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		items    int
+		fp       float64
+	}{
+		{"no growth", 1000, 500, 0.01},
+		{"grow a few times", 100, 2000, 0.01},
+		{"grow many times", 50, 10000, 0.001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewScalableBloomFilter(tt.capacity, tt.fp)
+			digest := func(i int) []byte {
+				sum := sha1.Sum([]byte(fmt.Sprintf("https://a.com/%d", i)))
+				return sum[:]
+			}
+
+			falsePositives := 0
+			for i := 0; i < tt.items; i++ {
+				if f.TestAndAdd(digest(i)) {
+					falsePositives++
+				}
+			}
+			if tt.items > tt.capacity && len(f.filters) < 2 {
+				t.Errorf("filter didn't grow, %d filters", len(f.filters))
+			}
+			// no false negative, everything added must be found again
+			for i := 0; i < tt.items; i++ {
+				if !f.TestAndAdd(digest(i)) {
+					t.Fatalf("item %d not found after %d filters", i, len(f.filters))
+				}
+			}
+			if rate := float64(falsePositives) / float64(tt.items); rate > tt.fp*5 {
+				t.Errorf("false positive rate %f is too high", rate)
+			}
+		})
+	}
+}
+
+func TestValidateBloom(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		fp       float64
+		wantErr  bool
+	}{
+		{"default", 1000000, 0.0001, false},
+		{"small", 1, 0.5, false},
+		{"zero fp", 1000, 0, true},
+		{"negative fp", 1000, -0.1, true},
+		{"fp is 1", 1000, 1, true},
+		{"fp over 1", 1000, 1.5, true},
+		{"zero size", 0, 0.01, true},
+		{"negative size", -10, 0.01, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBloom(tt.capacity, tt.fp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateBloom(%d, %v) error = %v, wantErr %v", tt.capacity, tt.fp, err, tt.wantErr)
+			}
+			if err == nil {
+				// valid options must not panic
+				f := NewScalableBloomFilter(tt.capacity, tt.fp)
+				f.TestAndAdd(make([]byte, 20))
+			}
+		})
+	}
+}
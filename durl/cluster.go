@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	PickFirst  = "first"
+	PickParams = "params"
+	PickQuery  = "query"
+	PickNewest = "newest"
+	PickRandom = "random"
+)
+
+// Record is one line of input
+type Record struct {
+	// URL used to calculate the pattern
+	URL string
+	// Line is the original input and printed as it is
+	Line string
+	Time string
+	// Fields are extra values from JSON input that take part in the dedupe key
+	Fields []string
+}
+
+// ParseRecord parse plain URL or JSON line like ourl -r, httpx -json
+func ParseRecord(line string) Record {
+	record := Record{URL: line, Line: line}
+	if !strings.HasPrefix(line, "{") || !gjson.Valid(line) {
+		return record
+	}
+
+	result := gjson.Parse(line)
+	record.URL = result.Get(urlField).String()
+	record.Time = result.Get(timeField).String()
+	for _, field := range keyFields {
+		record.Fields = append(record.Fields, fmt.Sprintf("%s=%s", field, result.Get(field).String()))
+	}
+	return record
+}
+
+// Cluster is a group of URLs that have the same pattern
+type Cluster struct {
+	Pattern        string
+	Count          int
+	Representative Record
+	Samples        []string
+}
+
+// Add add new member to cluster and pick the representative base on pick mode
+// maxSamples is 0 when there is no report so the samples are not kept
+func (c *Cluster) Add(r Record, pick string, maxSamples int) {
+	c.Count++
+	if len(c.Samples) < maxSamples {
+		c.Samples = append(c.Samples, r.URL)
+	}
+	if c.Count == 1 {
+		c.Representative = r
+		return
+	}
+
+	switch pick {
+	case PickParams:
+		// the param names are part of the pattern, so compare the values that are filled
+		if countValues(r.URL) > countValues(c.Representative.URL) {
+			c.Representative = r
+		}
+	case PickQuery:
+		if len(rawQuery(r.URL)) > len(rawQuery(c.Representative.URL)) {
+			c.Representative = r
+		}
+	case PickNewest:
+		// wayback timestamp like 20200101000000 so compare string is enough
+		if r.Time > c.Representative.Time {
+			c.Representative = r
+		}
+	case PickRandom:
+		// reservoir sampling so every member have the same chance
+		if rand.Intn(c.Count) == 0 {
+			c.Representative = r
+		}
+	}
+}
+
+// ClusterReport is one line of the JSONL report
+type ClusterReport struct {
+	Pattern        string   `json:"pattern"`
+	Count          int      `json:"count"`
+	Representative string   `json:"representative"`
+	Samples        []string `json:"samples"`
+}
+
+// GenReport sort clusters by the number of members
+func GenReport(clusters []*Cluster) []string {
+	sorted := append(clusters[:0:0], clusters...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Count > sorted[j].Count
+	})
+
+	var lines []string
+	for _, c := range sorted {
+		report := ClusterReport{
+			Pattern:        c.Pattern,
+			Count:          c.Count,
+			Representative: c.Representative.URL,
+			Samples:        c.Samples,
+		}
+		buf := &bytes.Buffer{}
+		enc := json.NewEncoder(buf)
+		// keep '&' in URLs as it is
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(report); err == nil {
+			lines = append(lines, strings.TrimSpace(buf.String()))
+		}
+	}
+	return lines
+}
+
+// countValues count the non-empty parameter values, e.g: ?a=1&b=&c=2 -> 2
+func countValues(raw string) int {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, values := range u.Query() {
+		for _, v := range values {
+			if v != "" {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func rawQuery(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.RawQuery
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClusterAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		pick    string
+		records []Record
+		want    string
+	}{
+		{
+			"first",
+			PickFirst,
+			[]Record{{URL: "https://a.com/?id=1"}, {URL: "https://a.com/?id=2"}},
+			"https://a.com/?id=1",
+		},
+		{
+			"params pick most filled values",
+			PickParams,
+			[]Record{{URL: "https://a.com/?id=&q="}, {URL: "https://a.com/?id=1&q="}, {URL: "https://a.com/?id=2&q=x"}, {URL: "https://a.com/?id=3&q=y"}},
+			"https://a.com/?id=2&q=x",
+		},
+		{
+			"query pick longest",
+			PickQuery,
+			[]Record{{URL: "https://a.com/?id=1"}, {URL: "https://a.com/?id=12345"}, {URL: "https://a.com/?id=12"}},
+			"https://a.com/?id=12345",
+		},
+		{
+			"newest",
+			PickNewest,
+			[]Record{{URL: "https://a.com/1", Time: "20200101000000"}, {URL: "https://a.com/2", Time: "20220101000000"}, {URL: "https://a.com/3", Time: "20210101000000"}},
+			"https://a.com/2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Cluster{}
+			for _, r := range tt.records {
+				c.Add(r, tt.pick, 0)
+			}
+			if c.Representative.URL != tt.want {
+				t.Errorf("representative = %s, want %s", c.Representative.URL, tt.want)
+			}
+			if c.Count != len(tt.records) {
+				t.Errorf("count = %d, want %d", c.Count, len(tt.records))
+			}
+			if len(c.Samples) != 0 {
+				t.Errorf("samples kept without report: %v", c.Samples)
+			}
+		})
+	}
+}
+
+func TestClusterAddRandom(t *testing.T) {
+	members := map[string]bool{}
+	c := &Cluster{}
+	for _, u := range []string{"https://a.com/1", "https://a.com/2", "https://a.com/3"} {
+		members[u] = true
+		c.Add(Record{URL: u}, PickRandom, 0)
+	}
+	if !members[c.Representative.URL] {
+		t.Errorf("representative %s is not a member", c.Representative.URL)
+	}
+}
+
+func TestClusterSamples(t *testing.T) {
+	c := &Cluster{}
+	for _, u := range []string{"https://a.com/1", "https://a.com/2", "https://a.com/3"} {
+		c.Add(Record{URL: u}, PickFirst, 2)
+	}
+	if want := []string{"https://a.com/1", "https://a.com/2"}; !reflect.DeepEqual(c.Samples, want) {
+		t.Errorf("samples = %v, want %v", c.Samples, want)
+	}
+}
+
+func TestGenReport(t *testing.T) {
+	small := &Cluster{Pattern: "a.com/{int}", Count: 1, Representative: Record{URL: "https://a.com/1"}, Samples: []string{"https://a.com/1"}}
+	big := &Cluster{Pattern: "a.com/?a&b", Count: 3, Representative: Record{URL: "https://a.com/?a=1&b=2"}, Samples: []string{"https://a.com/?a=1&b=2"}}
+	tie := &Cluster{Pattern: "a.com/x", Count: 1, Representative: Record{URL: "https://a.com/x"}}
+	clusters := []*Cluster{small, big, tie}
+
+	got := GenReport(clusters)
+	want := []string{
+		`{"pattern":"a.com/?a&b","count":3,"representative":"https://a.com/?a=1&b=2","samples":["https://a.com/?a=1&b=2"]}`,
+		`{"pattern":"a.com/{int}","count":1,"representative":"https://a.com/1","samples":["https://a.com/1"]}`,
+		`{"pattern":"a.com/x","count":1,"representative":"https://a.com/x","samples":null}`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("report =\n%v\nwant\n%v", got, want)
+	}
+	// the input order is kept for the output
+	if clusters[0] != small || clusters[1] != big {
+		t.Error("GenReport changed the order of the input")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"runtime"
 	"sort"
 	"strings"
 )
@@ -16,73 +17,237 @@ import (
 // cat urls.txt | durl
 // only grep url have parameter
 // cat urls.txt | durl -p
+// cluster URLs by path structure, /user/123 and /user/456 are the same
+// cat urls.txt | durl -s -show-pattern
+// cat urls.txt | durl -s -C 'sku=^[A-Z]{3}-[0-9]+$'
+// pick the URL with most parameters and write cluster statistics
+// cat urls.txt | durl -s -pick params -report clusters.jsonl
+// ourl -r target.com | durl -s -pick newest
+// billion-line dumps with bounded memory
+// cat wayback_urls.txt | durl -s -bloom -fp 0.0001
+// JSONL input, keep URLs with different status code
+// ourl -r target.com | durl -s -key status,mime
+// cat httpx.json | durl -s -url-field url -time-field timestamp -key status_code
 
 var (
-	blacklist bool
-	haveParam bool
-	ext       string
+	blacklist   bool
+	haveParam   bool
+	structure   bool
+	showPattern bool
+	ext         string
+	pickMode    string
+	reportFile  string
+	maxSamples  int
+	classifiers []Classifier
+
+	useBloom    bool
+	bloomFP     float64
+	bloomSize   int
+	concurrency int
+
+	urlField  string
+	timeField string
+	keyFields []string
+
+	blacklistRE *regexp.Regexp
+	paramRE     = regexp.MustCompile(`\?.*\=`)
+
+	stdout = bufio.NewWriterSize(os.Stdout, 64*1024)
 )
 
+// arrayFlags allow a flag to be repeated
+type arrayFlags []string
+
+func (a *arrayFlags) String() string {
+	return strings.Join(*a, ",")
+}
+
+func (a *arrayFlags) Set(value string) error {
+	*a = append(*a, value)
+	return nil
+}
+
 func main() {
 	// cli aguments
 	flag.BoolVar(&blacklist, "b", true, "Enable blacklist")
 	flag.BoolVar(&haveParam, "p", false, "Enable check if input have parameter")
 	flag.StringVar(&ext, "e", "", "Blacklist regex string (default is static extentions)")
+	flag.BoolVar(&structure, "s", false, "Cluster URLs by path structure (classify path segments like {int}, {uuid}, {hash})")
+	flag.BoolVar(&showPattern, "show-pattern", false, "Print the pattern of URL along with it")
+	var customClassifiers arrayFlags
+	flag.Var(&customClassifiers, "C", "Custom classifier in format name=regex, checked before default ones (can be repeated)")
+	flag.StringVar(&pickMode, "pick", PickFirst, "How to pick the representative of each cluster (first, params, query, newest, random)")
+	flag.StringVar(&reportFile, "report", "", "Write cluster statistics to JSONL file")
+	flag.IntVar(&maxSamples, "samples", 3, "Number of sample URLs per cluster in the report")
+	flag.BoolVar(&useBloom, "bloom", false, "Use scalable bloom filter instead of keeping every hash in memory (for really big input)")
+	flag.Float64Var(&bloomFP, "fp", 0.0001, "False positive rate of the bloom filter")
+	flag.IntVar(&bloomSize, "bloom-size", 1000000, "Initial capacity of the bloom filter")
+	flag.IntVar(&concurrency, "c", runtime.NumCPU(), "Number of workers to hash URLs")
+	// JSONL input
+	flag.StringVar(&urlField, "url-field", "url", "gjson path of URL when input is JSONL")
+	flag.StringVar(&timeField, "time-field", "time", "gjson path of timestamp when input is JSONL (used by -pick newest)")
+	var keys string
+	flag.StringVar(&keys, "key", "", "Extra gjson paths that take part in the dedupe key when input is JSONL (Ex: status,mime)")
 	flag.Parse()
 
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		if key != "" {
+			keyFields = append(keyFields, key)
+		}
+	}
+
+	switch pickMode {
+	case PickFirst, PickParams, PickQuery, PickNewest, PickRandom:
+	default:
+		fmt.Fprintf(os.Stderr, "invalid pick mode: %s\n", pickMode)
+		os.Exit(-1)
+	}
+
+	if structure || len(customClassifiers) > 0 {
+		for _, raw := range customClassifiers {
+			c, err := ParseClassifier(raw)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "failed to parse classifier: %v\n", err)
+				os.Exit(-1)
+			}
+			classifiers = append(classifiers, c)
+		}
+		if structure {
+			classifiers = append(classifiers, DefaultClassifiers...)
+		}
+	}
+
 	// default blacklist
 	if ext == "" {
 		ext = `(?i)\.(png|apng|bmp|gif|ico|cur|jpg|jpeg|jfif|pjp|pjpeg|svg|tif|tiff|webp|xbm|3gp|aac|flac|mpg|mpeg|mp3|mp4|m4a|m4v|m4p|oga|ogg|ogv|mov|wav|webm|eot|woff|woff2|ttf|otf|css)(?:\?|#|$)`
 	}
 
-	data := make(map[string]string)
-	sc := bufio.NewScanner(os.Stdin)
-	for sc.Scan() {
-		raw := strings.TrimSpace(sc.Text())
-		if sc.Err() != nil && raw == "" {
-			continue
-		}
+	defer stdout.Flush()
+
+	blacklistRE, _ = regexp.Compile(ext)
+	if blacklistRE == nil {
+		fmt.Fprintf(os.Stderr, "invalid blacklist regex: %s\n", ext)
+	}
 
-		if blacklist {
-			if IsBlacklisted(raw) {
-				continue
+	if useBloom {
+		if pickMode != PickFirst || reportFile != "" {
+			fmt.Fprintf(os.Stderr, "-bloom only support -pick first without -report\n")
+			os.Exit(-1)
+		}
+		if err := ValidateBloom(bloomSize, bloomFP); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(-1)
+		}
+		filter := NewScalableBloomFilter(bloomSize, bloomFP)
+		err := ProcessOrdered(os.Stdin, concurrency, processLine, func(entry *Entry) {
+			if !filter.TestAndAdd(entry.Hash) {
+				printEntry(entry.Pattern, entry.Record)
 			}
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read input: %v\n", err)
 		}
+		return
+	}
 
-		hash := hashUrl(raw)
-		if hash == "" {
-			continue
-		}
-		_, exist := data[hash]
+	// only the first one can be printed right away
+	streaming := pickMode == PickFirst
+
+	// samples are only used in the report
+	samples := 0
+	if reportFile != "" {
+		samples = maxSamples
+	}
+
+	data := make(map[string]*Cluster)
+	var clusters []*Cluster
+	err := ProcessOrdered(os.Stdin, concurrency, processLine, func(entry *Entry) {
+		cluster, exist := data[string(entry.Hash)]
 		if !exist {
-			data[hash] = raw
-			fmt.Println(raw)
+			cluster = &Cluster{Pattern: entry.Pattern}
+			data[string(entry.Hash)] = cluster
+			clusters = append(clusters, cluster)
+		}
+		cluster.Add(entry.Record, pickMode, samples)
+
+		if !exist && streaming {
+			printEntry(cluster.Pattern, cluster.Representative)
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read input: %v\n", err)
+	}
+
+	if !streaming {
+		for _, cluster := range clusters {
+			printEntry(cluster.Pattern, cluster.Representative)
+		}
+	}
+
+	if reportFile != "" {
+		report := GenReport(clusters)
+		if err := os.WriteFile(reportFile, []byte(strings.Join(report, "\n")+"\n"), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+		}
+	}
+}
+
+// Entry is a parsed input line
+type Entry struct {
+	Record  Record
+	Pattern string
+	Hash    []byte
+}
+
+// processLine parse and hash one line, return nil if it should be skipped
+func processLine(line string) *Entry {
+	record := ParseRecord(line)
+	if blacklist {
+		if IsBlacklisted(record.URL) {
+			return nil
 		}
 	}
+
+	pattern := urlPattern(record.URL)
+	if pattern == "" {
+		return nil
+	}
+	if len(record.Fields) > 0 {
+		pattern = fmt.Sprintf("%s %s", pattern, strings.Join(record.Fields, " "))
+	}
+	return &Entry{
+		Record:  record,
+		Pattern: pattern,
+		Hash:    genHash(pattern),
+	}
+}
+
+func printEntry(pattern string, record Record) {
+	if showPattern {
+		fmt.Fprintf(stdout, "%s\t%s\n", pattern, record.Line)
+		return
+	}
+	fmt.Fprintln(stdout, record.Line)
 }
 
 // IsBlacklisted check if url is blacklisted or not
 func IsBlacklisted(raw string) bool {
-	r, err := regexp.Compile(ext)
-	if err != nil {
-		return false
-	}
-	isBlacklisted := r.MatchString(raw)
-	if isBlacklisted {
+	if blacklistRE != nil && blacklistRE.MatchString(raw) {
 		return true
 	}
 
 	// check if have param
 	if haveParam {
-		p, _ := regexp.Compile(`\?.*\=`)
-		return !p.MatchString(raw)
+		return !paramRE.MatchString(raw)
 	}
 
 	return false
 }
 
-// hashUrl gen unique hash base on url
-func hashUrl(raw string) string {
+// urlPattern gen the pattern of url base on hostname-path-paramName
+// e.g: https://example.com/user/123?id=1&name=a -> example.com/user/{int}?id&name
+func urlPattern(raw string) string {
 	u, err := url.Parse(raw)
 	if err != nil {
 		return ""
@@ -93,15 +258,20 @@ func hashUrl(raw string) string {
 		queries = append(queries, k)
 	}
 	sort.Strings(queries)
-	query := strings.Join(queries, "-")
-	data := fmt.Sprintf("%v-%v-%v", u.Hostname(), u.Path, query)
-	return genHash(data)
+	query := strings.Join(queries, "&")
+
+	path := u.Path
+	if len(classifiers) > 0 {
+		path = PathPattern(path, classifiers)
+	}
+	if query == "" {
+		return fmt.Sprintf("%v%v", u.Hostname(), path)
+	}
+	return fmt.Sprintf("%v%v?%v", u.Hostname(), path, query)
 }
 
 // genHash gen SHA1 hash from string
-func genHash(text string) string {
-	h := sha1.New()
-	h.Write([]byte(text))
-	hashed := h.Sum(nil)
-	return fmt.Sprintf("%v", hashed)
+func genHash(text string) []byte {
+	hashed := sha1.Sum([]byte(text))
+	return hashed[:]
 }
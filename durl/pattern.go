@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Classifier turn a path segment into a placeholder like {int} when it matched
+type Classifier struct {
+	Name  string
+	Regex *regexp.Regexp
+	// Check is an extra condition for things that regexp can't express
+	Check func(segment string) bool
+}
+
+// Match check if path segment belong to the classifier
+func (c Classifier) Match(segment string) bool {
+	if !c.Regex.MatchString(segment) {
+		return false
+	}
+	if c.Check != nil {
+		return c.Check(segment)
+	}
+	return true
+}
+
+// DefaultClassifiers order matter, the first one matched win
+var DefaultClassifiers = []Classifier{
+	{Name: "uuid", Regex: regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)},
+	{Name: "date", Regex: regexp.MustCompile(`^(19|20)\d{2}[-_.]?(0[1-9]|1[0-2])[-_.]?(0[1-9]|[12]\d|3[01])$`)},
+	{Name: "int", Regex: regexp.MustCompile(`^\d+$`)},
+	{Name: "hash", Regex: regexp.MustCompile(`^[0-9a-fA-F]{8,}$`), Check: hasDigit},
+	{Name: "locale", Regex: regexp.MustCompile(`^[a-z]{2}[-_][a-zA-Z]{2}$`)},
+	{Name: "base64", Regex: regexp.MustCompile(`^[A-Za-z0-9+_-]{16,}={0,2}$`), Check: isMixedCase},
+	{Name: "slug", Regex: regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+){2,}$`)},
+}
+
+// ParseClassifier parse custom classifier in format: name=regex
+func ParseClassifier(raw string) (Classifier, error) {
+	parts := strings.SplitN(raw, "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return Classifier{}, fmt.Errorf("invalid classifier %s, expected name=regex", raw)
+	}
+	r, err := regexp.Compile(parts[1])
+	if err != nil {
+		return Classifier{}, err
+	}
+	return Classifier{Name: strings.TrimSpace(parts[0]), Regex: r}, nil
+}
+
+// PathPattern replace each path segment with its placeholder
+// e.g: /user/123/avatar.png -> /user/{int}/avatar.png
+func PathPattern(rawPath string, classifiers []Classifier) string {
+	segments := strings.Split(rawPath, "/")
+	for i, segment := range segments {
+		if segment == "" {
+			continue
+		}
+		// keep the extension so /123.json and /123.xml are different
+		ext := path.Ext(segment)
+		if strings.IndexFunc(ext, unicode.IsLetter) == -1 {
+			ext = ""
+		}
+		stem := strings.TrimSuffix(segment, ext)
+		if stem == "" {
+			continue
+		}
+		for _, c := range classifiers {
+			if c.Match(stem) {
+				segments[i] = fmt.Sprintf("{%s}%s", c.Name, ext)
+				break
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func hasDigit(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) != -1
+}
+
+func isMixedCase(s string) bool {
+	return hasDigit(s) && strings.IndexFunc(s, unicode.IsUpper) != -1 && strings.IndexFunc(s, unicode.IsLower) != -1
+}
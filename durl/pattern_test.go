@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestPathPattern(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"int", "/user/123", "/user/{int}"},
+		{"uuid", "/order/3f2b9c1e-8a4d-4e6f-9b1a-2c3d4e5f6a7b", "/order/{uuid}"},
+		{"date before int", "/archive/20200131", "/archive/{date}"},
+		{"date with separator", "/archive/2020-01-31", "/archive/{date}"},
+		{"invalid date is int", "/archive/20201331", "/archive/{int}"},
+		{"all digit hash is int", "/commit/12345678", "/commit/{int}"},
+		{"hex hash", "/commit/deadbeef01", "/commit/{hash}"},
+		{"hex word without digit", "/deadbeef", "/deadbeef"},
+		{"locale", "/en-US/docs", "/{locale}/docs"},
+		{"base64 before slug", "/token/aGVsbG8tV29ybGQx", "/token/{base64}"},
+		{"lower case slug is not base64", "/blog/how-to-install-go-1-16", "/blog/{slug}"},
+		{"short slug", "/about-us", "/about-us"},
+		{"keep extension", "/report/123.json", "/report/{int}.json"},
+		{"numeric extension is not extension", "/v1.2", "/v1.2"},
+		{"trailing slash", "/user/123/", "/user/{int}/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PathPattern(tt.path, DefaultClassifiers); got != tt.want {
+				t.Errorf("PathPattern(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomClassifierFirst(t *testing.T) {
+	c, err := ParseClassifier(`sku=^[A-Z]{3}-[0-9]+$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := PathPattern("/p/ABC-123/456", append([]Classifier{c}, DefaultClassifiers...))
+	if want := "/p/{sku}/{int}"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := ParseClassifier("no-regex"); err == nil {
+		t.Error("expected error for classifier without regex")
+	}
+}
+
+func TestURLPatternExtension(t *testing.T) {
+	defer func(old []Classifier) { classifiers = old }(classifiers)
+	classifiers = DefaultClassifiers
+
+	json := urlPattern("https://a.com/report/123.json?x=1")
+	xml := urlPattern("https://a.com/report/456.xml?x=2")
+	if json == xml {
+		t.Errorf("json and xml in the same cluster %q", json)
+	}
+	if same := urlPattern("https://a.com/report/789.json?x=3"); same != json {
+		t.Errorf("got %q, want %q", same, json)
+	}
+}
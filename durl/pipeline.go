@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+)
+
+// number of lines handed to a worker at a time
+const batchSize = 1000
+
+type batch struct {
+	lines   []string
+	entries []*Entry
+	done    chan struct{}
+}
+
+// ProcessOrdered run fn on every line with multiple workers
+// and call out with the results in the same order as the input
+func ProcessOrdered(r io.Reader, workers int, fn func(line string) *Entry, out func(entry *Entry)) error {
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan *batch, workers)
+	// keep track of the input order
+	queue := make(chan *batch, workers*2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				b.entries = make([]*Entry, len(b.lines))
+				for i, line := range b.lines {
+					b.entries[i] = fn(line)
+				}
+				close(b.done)
+			}
+		}()
+	}
+
+	var scanErr error
+	go func() {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+		current := &batch{done: make(chan struct{})}
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" {
+				continue
+			}
+			current.lines = append(current.lines, line)
+			if len(current.lines) >= batchSize {
+				queue <- current
+				jobs <- current
+				current = &batch{done: make(chan struct{})}
+			}
+		}
+		scanErr = sc.Err()
+		queue <- current
+		jobs <- current
+		close(queue)
+		close(jobs)
+	}()
+
+	for b := range queue {
+		<-b.done
+		for _, entry := range b.entries {
+			if entry != nil {
+				out(entry)
+			}
+		}
+	}
+	wg.Wait()
+	return scanErr
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestProcessOrdered(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   int
+		workers int
+	}{
+		{"single worker", 10, 1},
+		{"less than a batch", 500, 4},
+		{"many batches", batchSize*7 + 3, 8},
+		{"invalid workers", 20, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input []string
+			for i := 0; i < tt.lines; i++ {
+				input = append(input, fmt.Sprintf("https://a.com/%d", i))
+			}
+			// skip every 3rd line to check nil entries are dropped
+			fn := func(line string) *Entry {
+				var n int
+				fmt.Sscanf(line, "https://a.com/%d", &n)
+				if n%3 == 0 {
+					return nil
+				}
+				return &Entry{Pattern: line}
+			}
+
+			var got []string
+			err := ProcessOrdered(strings.NewReader(strings.Join(input, "\n")), tt.workers, fn, func(e *Entry) {
+				got = append(got, e.Pattern)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want []string
+			for i, line := range input {
+				if i%3 != 0 {
+					want = append(want, line)
+				}
+			}
+			if len(got) != len(want) {
+				t.Fatalf("got %d entries, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("entry %d = %s, want %s", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
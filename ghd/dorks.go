@@ -0,0 +1,136 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed dorks.yaml
+var defaultDorks []byte
+
+// Dork is a group of queries of the same kind
+type Dork struct {
+	Name     string   `yaml:"name"`
+	Category string   `yaml:"category"`
+	Tags     []string `yaml:"tags"`
+	Queries  []string `yaml:"queries"`
+	// Engines that the dork is made for, default is github
+	Engines []string `yaml:"engines"`
+	// Raw mean the queries are complete URLs and rendered as it is
+	Raw bool `yaml:"raw"`
+}
+
+// HasEngine check if the dork is made for the engine
+func (d Dork) HasEngine(name string) bool {
+	if len(d.Engines) == 0 {
+		return name == "github"
+	}
+	for _, e := range d.Engines {
+		if strings.ToLower(e) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag check category and tags of the dork
+func (d Dork) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if strings.ToLower(d.Category) == tag {
+		return true
+	}
+	for _, t := range d.Tags {
+		if strings.ToLower(t) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadDefaultDorks parse the embedded dorks
+func LoadDefaultDorks() ([]Dork, error) {
+	var dorks []Dork
+	if err := yaml.Unmarshal(defaultDorks, &dorks); err != nil {
+		return nil, fmt.Errorf("failed to parse default dorks: %v", err)
+	}
+	return dorks, nil
+}
+
+// LoadDorkFile load yaml dorks or plain text file with one dork URL per line
+func LoadDorkFile(filename string) ([]Dork, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == ".yaml" || ext == ".yml" {
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		var dorks []Dork
+		if err := yaml.Unmarshal(content, &dorks); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", filename, err)
+		}
+		return dorks, nil
+	}
+
+	if _, err := os.Stat(filename); err != nil {
+		return nil, err
+	}
+	var dorks []Dork
+	for i, line := range ReadingLines(filename) {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		dorks = append(dorks, Dork{
+			Name:     fmt.Sprintf("%s-%d", strings.TrimSuffix(filepath.Base(filename), ext), i+1),
+			Category: "custom",
+			Queries:  []string{line},
+			Raw:      strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://"),
+		})
+	}
+	return dorks, nil
+}
+
+// MergeDorks dorks of user override the default one with the same name, the others are appended
+func MergeDorks(base []Dork, extra []Dork) []Dork {
+	index := make(map[string]int)
+	for i, d := range base {
+		index[d.Name] = i
+	}
+	for _, d := range extra {
+		if i, ok := index[d.Name]; ok && d.Name != "" {
+			base[i] = d
+			continue
+		}
+		index[d.Name] = len(base)
+		base = append(base, d)
+	}
+	return base
+}
+
+// FilterDorks keep dorks that have any of tags and none of excludeTags
+func FilterDorks(dorks []Dork, tags []string, excludeTags []string) []Dork {
+	var results []Dork
+	for _, d := range dorks {
+		if len(tags) > 0 && !hasAnyTag(d, tags) {
+			continue
+		}
+		if hasAnyTag(d, excludeTags) {
+			continue
+		}
+		results = append(results, d)
+	}
+	return results
+}
+
+func hasAnyTag(d Dork, tags []string) bool {
+	for _, t := range tags {
+		if d.HasTag(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Engine translate the generic dork syntax to the syntax of a search engine
+// the generic qualifiers are: site, org, cert, title, filename, extension, path, language
+type Engine struct {
+	Name string
+	// Qualifiers translate qualifier value, the missing one become a quoted term
+	Qualifiers map[string]func(value string) string
+	// Term translate a free term
+	Term func(term string) string
+	// Not negate a translated token
+	Not       func(token string) string
+	Operators map[string]string
+	Join      string
+	SearchURL func(query string) string
+}
+
+func prefix(p string) func(string) string {
+	return func(v string) string { return p + v }
+}
+
+func prefixQuote(p string) func(string) string {
+	return func(v string) string { return p + quote(v) }
+}
+
+func quote(v string) string {
+	return `"` + strings.Trim(v, `"`) + `"`
+}
+
+func keep(v string) string {
+	return v
+}
+
+// Engines available with -engine
+var Engines = map[string]Engine{
+	"github": {
+		Name: "github",
+		Qualifiers: map[string]func(string) string{
+			"site":      quote,
+			"cert":      quote,
+			"org":       prefix("org:"),
+			"filename":  prefix("filename:"),
+			"extension": prefix("extension:"),
+			"path":      prefix("path:"),
+			"language":  prefix("language:"),
+		},
+		Term: keep,
+		Not:  prefix("NOT "),
+		Join: " ",
+		SearchURL: func(q string) string {
+			return fmt.Sprintf("https://github.com/search?q=%s&type=Code", url.QueryEscape(q))
+		},
+	},
+	"google": {
+		Name: "google",
+		Qualifiers: map[string]func(string) string{
+			"site":      prefix("site:"),
+			"filename":  prefix("inurl:"),
+			"path":      prefix("inurl:"),
+			"extension": prefix("ext:"),
+			"title":     prefixQuote("intitle:"),
+		},
+		Term: keep,
+		Not:  prefix("-"),
+		Join: " ",
+		SearchURL: func(q string) string {
+			return fmt.Sprintf("https://www.google.com/search?q=%s", url.QueryEscape(q))
+		},
+	},
+	"shodan": {
+		Name: "shodan",
+		Qualifiers: map[string]func(string) string{
+			"site":  prefix("hostname:"),
+			"cert":  prefix("ssl.cert.subject.cn:"),
+			"org":   prefixQuote("org:"),
+			"title": prefixQuote("http.title:"),
+		},
+		Term: keep,
+		Not:  prefix("-"),
+		Join: " ",
+		SearchURL: func(q string) string {
+			return fmt.Sprintf("https://www.shodan.io/search?query=%s", url.QueryEscape(q))
+		},
+	},
+	"censys": {
+		Name: "censys",
+		Qualifiers: map[string]func(string) string{
+			"site":  prefixQuote("dns.names: "),
+			"cert":  prefixQuote("services.tls.certificates.leaf_data.subject.common_name: "),
+			"org":   prefixQuote("services.tls.certificates.leaf_data.subject.organization: "),
+			"title": prefixQuote("services.http.response.html_title: "),
+		},
+		Term:      keep,
+		Not:       prefix("not "),
+		Operators: map[string]string{"OR": "or", "AND": "and"},
+		Join:      " and ",
+		SearchURL: func(q string) string {
+			return fmt.Sprintf("https://search.censys.io/search?resource=hosts&q=%s", url.QueryEscape(q))
+		},
+	},
+	"fofa": {
+		Name: "fofa",
+		Qualifiers: map[string]func(string) string{
+			"site":     prefixQuote("domain="),
+			"cert":     prefixQuote("cert="),
+			"org":      prefixQuote("org="),
+			"title":    prefixQuote("title="),
+			"filename": prefixQuote("body="),
+			"path":     prefixQuote("body="),
+		},
+		Term: quote,
+		Not: func(token string) string {
+			if strings.Contains(token, "=") {
+				return strings.Replace(token, "=", "!=", 1)
+			}
+			return "body!=" + token
+		},
+		Operators: map[string]string{"OR": "||", "AND": "&&"},
+		Join:      " && ",
+		SearchURL: func(q string) string {
+			return fmt.Sprintf("https://fofa.info/result?qbase64=%s", url.QueryEscape(base64.StdEncoding.EncodeToString([]byte(q))))
+		},
+	},
+}
+
+// EngineNames in the order of output
+var EngineNames = []string{"github", "google", "shodan", "censys", "fofa"}
+
+// Translate the rendered dork to the syntax of the engine
+func (e Engine) Translate(query string) string {
+	var b strings.Builder
+	negate := false
+	// the join of the engine isn't added around the operators
+	joinNext := false
+	for _, token := range tokenize(query) {
+		upper := strings.ToUpper(token)
+		if upper == "NOT" {
+			negate = true
+			continue
+		}
+		if upper == "OR" || upper == "AND" {
+			op := upper
+			if v, ok := e.Operators[upper]; ok {
+				op = v
+			}
+			b.WriteString(" " + op + " ")
+			joinNext = false
+			continue
+		}
+		if strings.HasPrefix(token, "-") && len(token) > 1 {
+			negate = true
+			token = token[1:]
+		}
+
+		out := e.translateToken(token)
+		if negate {
+			out = e.Not(out)
+			negate = false
+		}
+		if joinNext {
+			b.WriteString(e.Join)
+		}
+		b.WriteString(out)
+		joinNext = true
+	}
+	return b.String()
+}
+
+func (e Engine) translateToken(token string) string {
+	if name, value, ok := splitQualifier(token); ok {
+		if fn, ok := e.Qualifiers[name]; ok {
+			return fn(value)
+		}
+		if _, known := genericQualifiers[name]; known {
+			return e.Term(quote(value))
+		}
+	}
+	return e.Term(token)
+}
+
+var genericQualifiers = map[string]bool{
+	"site": true, "org": true, "cert": true, "title": true,
+	"filename": true, "extension": true, "path": true, "language": true,
+}
+
+// splitQualifier site:example.com -> site, example.com
+func splitQualifier(token string) (string, string, bool) {
+	if strings.HasPrefix(token, `"`) {
+		return "", "", false
+	}
+	i := strings.Index(token, ":")
+	if i <= 0 {
+		return "", "", false
+	}
+	name := strings.ToLower(token[:i])
+	if !genericQualifiers[name] {
+		return "", "", false
+	}
+	return name, strings.Trim(token[i+1:], `"`), true
+}
+
+// tokenize split query by spaces, the quoted strings are kept together
+func tokenize(query string) []string {
+	var tokens []string
+	var cur strings.Builder
+	inQuote := false
+	for _, c := range query {
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+			cur.WriteRune(c)
+		case (c == ' ' || c == '\t') && !inQuote:
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(c)
+		}
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
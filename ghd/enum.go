@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// orgSuffixes are common suffixes of company accounts on Github
+var orgSuffixes = []string{"inc", "hq", "labs", "dev", "io", "oss", "opensource", "eng", "engineering", "tech", "official", "team", "corp", "group", "security", "app"}
+
+var githubLoginRE = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// OrgCandidates gen possible Github organization names of the target
+// e.g: energy.tesla.com -> tesla, tesla-com, teslacom, tesla-energy, energy-tesla, tesla-inc, teslainc, ...
+func OrgCandidates(raw string) []string {
+	data := ParseURL(raw)
+	domain := strings.ToLower(data["Domain"])
+	root, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		root = domain
+	}
+	suffix, _ := publicsuffix.PublicSuffix(root)
+	name := strings.TrimSuffix(strings.TrimSuffix(root, suffix), ".")
+	if name == "" {
+		return nil
+	}
+
+	var results []string
+	seen := make(map[string]bool)
+	add := func(candidate string) {
+		candidate = strings.ToLower(candidate)
+		if len(candidate) > 39 || seen[candidate] || !githubLoginRE.MatchString(candidate) {
+			return
+		}
+		seen[candidate] = true
+		results = append(results, candidate)
+	}
+
+	// no-TLD and hyphenated forms
+	add(name)
+	add(strings.ReplaceAll(root, ".", "-"))
+	add(strings.ReplaceAll(root, ".", ""))
+	add(strings.ReplaceAll(name, "-", ""))
+	add(strings.ReplaceAll(name, "_", "-"))
+
+	// subdomain labels like energy.tesla.com
+	sub := strings.TrimSuffix(strings.TrimSuffix(domain, root), ".")
+	for _, label := range strings.Split(sub, ".") {
+		if label == "" || label == "www" {
+			continue
+		}
+		add(name + "-" + label)
+		add(label + "-" + name)
+		add(name + label)
+	}
+
+	// suffixed forms
+	for _, s := range orgSuffixes {
+		add(name + "-" + s)
+		add(name + s)
+	}
+	return results
+}
+
+type githubAccount struct {
+	Login string `json:"login"`
+	Type  string `json:"type"`
+}
+
+type githubRepo struct {
+	FullName string `json:"full_name"`
+}
+
+// Enumerator check org candidates via Github API and emit dork seeds
+type Enumerator struct {
+	*GithubClient
+	MaxPages int
+}
+
+// Account return the Github account of login, nil if it doesn't exist
+func (e *Enumerator) Account(login string) (*githubAccount, error) {
+	status, body, err := e.Get("/users/"+url.PathEscape(login), nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("status %d: %s", status, strings.TrimSpace(string(body)))
+	}
+	var account githubAccount
+	if err := json.Unmarshal(body, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// list page through an API path that return an array
+func (e *Enumerator) list(path string, fn func(body []byte) (int, error)) error {
+	for page := 1; page <= e.MaxPages; page++ {
+		params := url.Values{}
+		params.Set("per_page", "100")
+		params.Set("page", strconv.Itoa(page))
+		status, body, err := e.Get(path, params, "")
+		if err != nil {
+			return err
+		}
+		if status != http.StatusOK {
+			return fmt.Errorf("status %d: %s", status, strings.TrimSpace(string(body)))
+		}
+		n, err := fn(body)
+		if err != nil {
+			return err
+		}
+		if n < 100 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Enumerate check candidates of the target and call fn with org:, user: and repo: seeds
+// errors are logged to stderr so one failed candidate doesn't stop the others
+func (e *Enumerator) Enumerate(raw string, fn func(seed string)) {
+	for _, candidate := range OrgCandidates(raw) {
+		account, err := e.Account(candidate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] %s: %v\n", candidate, err)
+			continue
+		}
+		if account == nil {
+			continue
+		}
+		if account.Type != "Organization" {
+			fn("user:" + account.Login)
+			continue
+		}
+		fn("org:" + account.Login)
+
+		err = e.list("/orgs/"+url.PathEscape(account.Login)+"/public_members", func(body []byte) (int, error) {
+			var members []githubAccount
+			if err := json.Unmarshal(body, &members); err != nil {
+				return 0, err
+			}
+			for _, m := range members {
+				fn("user:" + m.Login)
+			}
+			return len(members), nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] %s members: %v\n", account.Login, err)
+		}
+
+		err = e.list("/orgs/"+url.PathEscape(account.Login)+"/repos", func(body []byte) (int, error) {
+			var repos []githubRepo
+			if err := json.Unmarshal(body, &repos); err != nil {
+				return 0, err
+			}
+			for _, r := range repos {
+				fn("repo:" + r.FullName)
+			}
+			return len(repos), nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] %s repos: %v\n", account.Login, err)
+		}
+	}
+}
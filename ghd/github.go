@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// token with its rate limit state
+type apiToken struct {
+	value string
+	// blockedUntil is set when the token is out of quota or got Retry-After
+	blockedUntil time.Time
+}
+
+// GithubClient call Github REST API with tokens in rotation
+type GithubClient struct {
+	BaseURL string
+	// MaxRetries of a request when hit the rate limit
+	MaxRetries int
+
+	client *http.Client
+	tokens []*apiToken
+	next   int
+	mu     sync.Mutex
+}
+
+// NewGithubClient create client, baseURL is https://api.github.com or https://host/api/v3 for Enterprise
+// requests are anonymous when there is no token
+func NewGithubClient(baseURL string, tokens []string) *GithubClient {
+	c := &GithubClient{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		MaxRetries: 5,
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, t := range tokens {
+		c.tokens = append(c.tokens, &apiToken{value: t})
+	}
+	if len(c.tokens) == 0 {
+		c.tokens = append(c.tokens, &apiToken{})
+	}
+	return c
+}
+
+// pickToken return the next usable token, wait when all of them are blocked
+func (c *GithubClient) pickToken() *apiToken {
+	for {
+		c.mu.Lock()
+		var earliest time.Time
+		now := time.Now()
+		for i := 0; i < len(c.tokens); i++ {
+			t := c.tokens[(c.next+i)%len(c.tokens)]
+			if !t.blockedUntil.After(now) {
+				c.next = (c.next + i + 1) % len(c.tokens)
+				c.mu.Unlock()
+				return t
+			}
+			if earliest.IsZero() || t.blockedUntil.Before(earliest) {
+				earliest = t.blockedUntil
+			}
+		}
+		c.mu.Unlock()
+
+		wait := time.Until(earliest)
+		if verbose {
+			fmt.Fprintf(os.Stderr, "[INFO] all tokens are rate limited, sleeping %v\n", wait.Round(time.Second))
+		}
+		time.Sleep(wait)
+	}
+}
+
+// updateLimit read X-RateLimit-* and Retry-After headers of response, return true when the token is limited
+func (c *GithubClient) updateLimit(t *apiToken, resp *http.Response, body []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if retry := resp.Header.Get("Retry-After"); retry != "" {
+		t.blockedUntil = time.Now().Add(parseRetryAfter(retry))
+		return true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			t.blockedUntil = time.Unix(reset, 0).Add(time.Second)
+		} else {
+			t.blockedUntil = time.Now().Add(minBackoff)
+		}
+		return resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests
+	}
+	// secondary rate limit without any header
+	if resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode == http.StatusForbidden && strings.Contains(strings.ToLower(string(body)), "rate limit")) {
+		t.blockedUntil = time.Now().Add(time.Minute)
+		return true
+	}
+	return false
+}
+
+// minBackoff is used when Retry-After or X-RateLimit-Reset can't be parsed
+const minBackoff = time.Minute
+
+// parseRetryAfter accept both delay-seconds and HTTP-date
+func parseRetryAfter(value string) time.Duration {
+	if sec, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+		return 0
+	}
+	return minBackoff
+}
+
+// Get call the API path and retry with other tokens when hit the rate limit
+func (c *GithubClient) Get(path string, params url.Values, accept string) (int, []byte, error) {
+	apiURL := c.BaseURL + path
+	if len(params) > 0 {
+		apiURL += "?" + params.Encode()
+	}
+
+	for retry := 0; retry <= c.MaxRetries; retry++ {
+		t := c.pickToken()
+		req, err := http.NewRequest("GET", apiURL, nil)
+		if err != nil {
+			return 0, nil, err
+		}
+		if accept == "" {
+			accept = "application/vnd.github+json"
+		}
+		req.Header.Set("Accept", accept)
+		if t.value != "" {
+			req.Header.Set("Authorization", "token "+t.value)
+		}
+		req.Header.Set("User-Agent", "ghd")
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return 0, nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return 0, nil, err
+		}
+		if c.updateLimit(t, resp, body) {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "[WARN] rate limited on %s\n", apiURL)
+			}
+			continue
+		}
+		return resp.StatusCode, body, nil
+	}
+	return 0, nil, fmt.Errorf("give up %s after %d retries", apiURL, c.MaxRetries)
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxLocalFileSize skip big files in local mode
+const maxLocalFileSize = 5 * 1024 * 1024
+
+// languages by file extension for language: qualifier
+var languages = map[string]string{
+	"go": "go", "py": "python", "rb": "ruby", "php": "php", "java": "java",
+	"js": "javascript", "mjs": "javascript", "jsx": "javascript", "ts": "typescript", "tsx": "typescript",
+	"sh": "shell", "bash": "shell", "zsh": "shell", "ps1": "powershell",
+	"c": "c", "h": "c", "cpp": "c++", "cs": "c#", "rs": "rust", "kt": "kotlin", "swift": "swift",
+	"ex": "elixir", "exs": "elixir", "scala": "scala", "pl": "perl", "lua": "lua",
+	"yml": "yaml", "yaml": "yaml", "json": "json", "xml": "xml", "toml": "toml", "ini": "ini",
+	"html": "html", "css": "css", "sql": "sql", "md": "markdown", "tf": "hcl", "dockerfile": "dockerfile",
+}
+
+// queryTerm is a qualifier or a term to search in content
+type queryTerm struct {
+	qualifier string
+	value     string
+	negate    bool
+}
+
+// LocalQuery is a github query parsed for offline evaluation
+// the clauses are AND-ed, the terms in a clause are OR-ed
+type LocalQuery struct {
+	Dork    string
+	Query   string
+	clauses [][]queryTerm
+}
+
+// LocalMatch is a matched file of local mode
+type LocalMatch struct {
+	Dork   string `json:"dork"`
+	Query  string `json:"query"`
+	Repo   string `json:"repo"`
+	Commit string `json:"commit,omitempty"`
+	Path   string `json:"path"`
+	Line   int    `json:"line,omitempty"`
+	Text   string `json:"text,omitempty"`
+}
+
+// ParseLocalQuery parse filename:, extension:, path:, language:, quoted terms, NOT and OR
+// the qualifiers that can't be evaluated offline like org: or repo: are ignored
+func ParseLocalQuery(dork string, query string) LocalQuery {
+	q := LocalQuery{Dork: dork, Query: query}
+	negate, or := false, false
+	for _, token := range tokenize(query) {
+		switch strings.ToUpper(token) {
+		case "NOT":
+			negate = true
+			continue
+		case "OR":
+			or = true
+			continue
+		case "AND":
+			continue
+		}
+		if strings.HasPrefix(token, "-") && len(token) > 1 {
+			negate = true
+			token = token[1:]
+		}
+
+		t := queryTerm{negate: negate}
+		negate = false
+		if i := strings.Index(token, ":"); i > 0 && !strings.HasPrefix(token, `"`) {
+			name := strings.ToLower(token[:i])
+			switch name {
+			case "filename", "extension", "path", "language":
+				t.qualifier = name
+				t.value = strings.ToLower(strings.Trim(token[i+1:], `"`))
+			case "org", "repo", "user", "in", "size", "fork":
+				or = false
+				continue
+			}
+		}
+		if t.qualifier == "" {
+			t.value = strings.ToLower(strings.Trim(token, `"`))
+		}
+		if t.value == "" {
+			or = false
+			continue
+		}
+
+		if or && len(q.clauses) > 0 {
+			last := len(q.clauses) - 1
+			q.clauses[last] = append(q.clauses[last], t)
+		} else {
+			q.clauses = append(q.clauses, []queryTerm{t})
+		}
+		or = false
+	}
+	return q
+}
+
+// Empty is true when nothing of the query can be evaluated offline like org:name
+func (q LocalQuery) Empty() bool {
+	return len(q.clauses) == 0
+}
+
+// matchPath evaluate a qualifier on the path, ok is false for content terms
+func (t queryTerm) matchPath(relPath string) (matched bool, ok bool) {
+	relPath = strings.ToLower(filepath.ToSlash(relPath))
+	base := path.Base(relPath)
+	ext := strings.TrimPrefix(path.Ext(base), ".")
+	switch t.qualifier {
+	case "filename":
+		g, _ := path.Match(t.value, base)
+		return base == t.value || g, true
+	case "extension":
+		return ext == strings.TrimPrefix(t.value, "."), true
+	case "path":
+		p := strings.Trim(t.value, "/")
+		return strings.HasPrefix(relPath, p+"/") || strings.Contains(relPath, "/"+p+"/") || strings.HasPrefix(relPath, p), true
+	case "language":
+		lang := languages[ext]
+		if base == "dockerfile" {
+			lang = "dockerfile"
+		}
+		return lang == t.value, true
+	}
+	return false, false
+}
+
+// Match evaluate the query on a file, content is loaded only when needed
+// lines are the lines contain any of the content terms
+func (q LocalQuery) Match(relPath string, content func() []byte) (bool, []int) {
+	var lower []byte
+	load := func() []byte {
+		if lower == nil {
+			lower = bytes.ToLower(content())
+		}
+		return lower
+	}
+
+	// check the qualifiers first to avoid reading the file
+	for _, pass := range []bool{true, false} {
+		for _, clause := range q.clauses {
+			if isPathClause(clause) != pass {
+				continue
+			}
+			matched := false
+			for _, t := range clause {
+				m, ok := t.matchPath(relPath)
+				if !ok {
+					m = bytes.Contains(load(), []byte(t.value)) || strings.Contains(strings.ToLower(relPath), t.value)
+				}
+				if m != t.negate {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return false, nil
+			}
+		}
+	}
+
+	var terms [][]byte
+	for _, clause := range q.clauses {
+		for _, t := range clause {
+			if t.qualifier == "" && !t.negate {
+				terms = append(terms, []byte(t.value))
+			}
+		}
+	}
+	if len(terms) == 0 {
+		return true, nil
+	}
+	var lines []int
+	for i, line := range bytes.Split(load(), []byte("\n")) {
+		for _, term := range terms {
+			if bytes.Contains(line, term) {
+				lines = append(lines, i+1)
+				break
+			}
+		}
+	}
+	return true, lines
+}
+
+func isPathClause(clause []queryTerm) bool {
+	for _, t := range clause {
+		if t.qualifier == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// isBinary check NUL byte at the beginning like git
+func isBinary(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// evaluateFile run all queries on a file and report matches
+func evaluateFile(queries []LocalQuery, repo, commit, relPath string, read func() []byte, fn func(LocalMatch)) {
+	var content []byte
+	loaded := false
+	load := func() []byte {
+		if !loaded {
+			content = read()
+			loaded = true
+		}
+		return content
+	}
+	for _, q := range queries {
+		ok, lines := q.Match(relPath, load)
+		if !ok || (loaded && isBinary(content)) {
+			continue
+		}
+		base := LocalMatch{Dork: q.Dork, Query: q.Query, Repo: repo, Commit: commit, Path: relPath}
+		if len(lines) == 0 {
+			fn(base)
+			continue
+		}
+		all := bytes.Split(content, []byte("\n"))
+		for _, n := range lines {
+			m := base
+			m.Line = n
+			m.Text = strings.TrimSpace(string(all[n-1]))
+			fn(m)
+		}
+	}
+}
+
+// ScanDir evaluate queries on every file of the directory tree
+func ScanDir(root string, queries []LocalQuery, fn func(LocalMatch)) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxLocalFileSize {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		evaluateFile(queries, root, "", filepath.ToSlash(rel), func() []byte {
+			content, _ := os.ReadFile(p)
+			return content
+		}, fn)
+		return nil
+	})
+}
+
+// ScanGitHistory evaluate queries on every file of every commit, the same blob at the same path is checked once
+func ScanGitHistory(root string, queries []LocalQuery, fn func(LocalMatch)) error {
+	out, err := exec.Command("git", "-C", root, "rev-list", "--all").Output()
+	if err != nil {
+		return fmt.Errorf("failed to list commits of %s: %v", root, err)
+	}
+
+	// one cat-file process to read every blob
+	cat := exec.Command("git", "-C", root, "cat-file", "--batch")
+	stdin, err := cat.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cat.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cat.Start(); err != nil {
+		return err
+	}
+	defer func() {
+		stdin.Close()
+		cat.Wait()
+	}()
+	reader := bufio.NewReader(stdout)
+	readBlob := func(hash string) []byte {
+		fmt.Fprintln(stdin, hash)
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return nil
+		}
+		size, _ := strconv.Atoi(fields[2])
+		buf := make([]byte, size+1)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil
+		}
+		return buf[:size]
+	}
+
+	seen := make(map[string]bool)
+	for _, commit := range strings.Fields(string(out)) {
+		tree, err := exec.Command("git", "-C", root, "ls-tree", "-r", "-l", "-z", commit).Output()
+		if err != nil {
+			continue
+		}
+		for _, entry := range strings.Split(string(tree), "\x00") {
+			// <mode> blob <hash> <size>\t<path>
+			parts := strings.SplitN(entry, "\t", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			meta := strings.Fields(parts[0])
+			if len(meta) != 4 || meta[1] != "blob" {
+				continue
+			}
+			key := meta[2] + "\t" + parts[1]
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if size, err := strconv.Atoi(meta[3]); err != nil || size > maxLocalFileSize {
+				continue
+			}
+			hash := meta[2]
+			evaluateFile(queries, root, commit, parts[1], func() []byte { return readBlob(hash) }, fn)
+		}
+	}
+	return nil
+}
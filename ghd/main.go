@@ -3,12 +3,12 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"golang.org/x/net/publicsuffix"
 	"net/url"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 	"text/template"
@@ -18,22 +18,100 @@ var (
 	verbose     bool
 	concurrency int
 
-	dorkFile string
-	data     string
-	dataFile string
+	dorkFile    string
+	data        string
+	dataFile    string
+	tags        string
+	excludeTags string
+	listMode    bool
+	noDefault   bool
+	engines     string
+	queryOnly   bool
+	searchMode  bool
+	tokens      string
+	apiURL      string
+	maxPages    int
+	localDirs   string
+	allCommits  bool
+	enumMode    bool
 )
 
 func main() {
 	// cli args
 	flag.StringVar(&data, "u", "", "URL to open")
 	flag.StringVar(&dataFile, "U", "", "URL file to open")
-	flag.StringVar(&dorkFile, "d", "", "Dorks file")
+	flag.StringVar(&dorkFile, "d", "", "Dorks file (yaml or one dork per line), override or extend the default dorks")
+	flag.StringVar(&tags, "tag", "", "Only use dorks with these categories or tags (comma separated)")
+	flag.StringVar(&excludeTags, "exclude-tag", "", "Skip dorks with these categories or tags (comma separated)")
+	flag.BoolVar(&listMode, "list", false, "List available dorks then exit")
+	flag.BoolVar(&noDefault, "no-default", false, "Don't use the default dorks, only the ones from -d")
+	flag.StringVar(&engines, "engine", "github", "Search engines to render dorks for (comma separated): github, google, shodan, censys, fofa, all")
+	flag.BoolVar(&queryOnly, "query", false, "Print the translated query instead of the search URL")
+	flag.BoolVar(&searchMode, "search", false, "Run the dorks with Github code search API and print results as JSONL")
+	flag.StringVar(&tokens, "token", "", "Github tokens used in rotation (comma separated), default is GITHUB_TOKEN env")
+	flag.StringVar(&apiURL, "api", "https://api.github.com", "Github API base URL (e.g: https://github.example.com/api/v3)")
+	flag.IntVar(&maxPages, "pages", 10, "Max pages of results for each dork in search mode")
+	flag.StringVar(&localDirs, "local", "", "Evaluate github dorks offline over these directories (comma separated) and print matches as JSONL")
+	flag.BoolVar(&allCommits, "all-commits", false, "Evaluate every commit of the git history in local mode")
+	flag.BoolVar(&enumMode, "enum", false, "Enumerate Github organizations, members and repositories of the target then print org:/user:/repo: seeds")
 	flag.BoolVar(&verbose, "v", false, "verbose mode")
 	flag.IntVar(&concurrency, "c", 5, "number of tab at a time")
 	flag.Parse()
 
+	// get dork data
+	var dorks []Dork
+	if !noDefault {
+		defaults, err := LoadDefaultDorks()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(-1)
+		}
+		dorks = defaults
+	}
+	if dorkFile == "" {
+		dorkFile = os.Getenv("GH_DORKS")
+	}
+	if dorkFile != "" {
+		userDorks, err := LoadDorkFile(dorkFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load dork file: %v\n", err)
+			os.Exit(-1)
+		}
+		dorks = MergeDorks(dorks, userDorks)
+	}
+	dorks = FilterDorks(dorks, splitList(tags), splitList(excludeTags))
+
+	var selected []Engine
+	for _, name := range splitList(engines) {
+		name = strings.ToLower(name)
+		if name == "all" {
+			selected = selected[:0]
+			for _, n := range EngineNames {
+				selected = append(selected, Engines[n])
+			}
+			break
+		}
+		e, ok := Engines[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown engine: %s\n", name)
+			os.Exit(-1)
+		}
+		selected = append(selected, e)
+	}
+
+	if listMode {
+		for _, d := range dorks {
+			engineList := "github"
+			if len(d.Engines) > 0 {
+				engineList = strings.Join(d.Engines, ",")
+			}
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\n", d.Name, d.Category, strings.Join(d.Tags, ","), engineList, strings.Join(d.Queries, " | "))
+		}
+		return
+	}
+
 	// detect if anything came from std
-	var templateData, urls []string
+	var urls []string
 
 	stat, _ := os.Stdin.Stat()
 	if (stat.Mode() & os.ModeCharDevice) == 0 {
@@ -54,32 +132,199 @@ func main() {
 		urls = append(urls, ReadingLines(dataFile)...)
 	}
 
-	// get dork data
-	if dorkFile == "" {
-		goPath, ok := os.LookupEnv("GOPATH")
-		if ok {
-			dorkFile = path.Join(goPath, "src/github.com/j3ssie/go-auxs/ghd/dorks.txt")
-		}
+	// enum doesn't use the dorks
+	if enumMode {
+		runEnum(urls)
+		return
+	}
+	if len(dorks) == 0 {
+		fmt.Fprintf(os.Stderr, "No dork selected\n")
+		os.Exit(-1)
+	}
+	if searchMode {
+		runSearch(dorks, urls)
+		return
+	}
+	if localDirs != "" {
+		runLocal(dorks, urls)
+		return
+	}
 
-		ghDork, ok := os.LookupEnv("GH_DORKS")
-		if ok {
-			dorkFile = ghDork
+	for _, u := range urls {
+		data := ParseURL(u)
+		for _, e := range selected {
+			for _, d := range dorks {
+				if !d.HasEngine(e.Name) {
+					continue
+				}
+				for _, raw := range d.Queries {
+					out := RenderTemplate(raw, data)
+					if !d.Raw {
+						out = e.Translate(out)
+						if !queryOnly {
+							out = e.SearchURL(out)
+						}
+					}
+					fmt.Println(out)
+				}
+			}
 		}
 	}
+}
 
-	if dorkFile == "" {
-		fmt.Fprintf(os.Stderr, "Need to provide dork file via -d dorks.txt \n")
+// runSearch execute github dorks and print the results as JSONL
+func runSearch(dorks []Dork, urls []string) {
+	if tokens == "" {
+		tokens = os.Getenv("GITHUB_TOKEN")
+	}
+	searcher, err := NewSearcher(apiURL, splitList(tokens))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(-1)
 	}
-	templateData = ReadingLines(dorkFile)
+	searcher.MaxPages = maxPages
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	for _, u := range urls {
+		data := ParseURL(u)
+		for _, d := range dorks {
+			if !d.HasEngine("github") {
+				continue
+			}
+			queries, _ := githubQueries(d, data)
+			for _, query := range queries {
+				if verbose {
+					fmt.Fprintf(os.Stderr, "[INFO] searching %s\n", query)
+				}
+				err := searcher.Search(d.Name, query, func(r SearchResult) {
+					enc.Encode(r)
+				})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "[ERROR] %s: %v\n", query, err)
+				}
+			}
+		}
+	}
+}
+
+// runEnum print confirmed github accounts of the targets as dork seeds
+func runEnum(urls []string) {
+	if tokens == "" {
+		tokens = os.Getenv("GITHUB_TOKEN")
+	}
+	e := &Enumerator{GithubClient: NewGithubClient(apiURL, splitList(tokens)), MaxPages: maxPages}
+	seen := make(map[string]bool)
+	for _, u := range urls {
+		e.Enumerate(u, func(seed string) {
+			if !seen[seed] {
+				seen[seed] = true
+				fmt.Println(seed)
+			}
+		})
+	}
+}
+
+// githubQueries render github dorks for the input
+// the queries need variables that are missing in data are skipped and counted
+func githubQueries(d Dork, data map[string]string) ([]string, int) {
+	var queries []string
+	skipped := 0
+	for _, raw := range d.Queries {
+		query, err := RenderStrict(raw, data)
+		if err != nil {
+			skipped++
+			continue
+		}
+		if d.Raw {
+			query = queryFromURL(query)
+		} else {
+			query = Engines["github"].Translate(query)
+		}
+		if query != "" {
+			queries = append(queries, query)
+		}
+	}
+	return queries, skipped
+}
 
+// runLocal evaluate github dorks over local directories
+func runLocal(dorks []Dork, urls []string) {
+	// dorks without input are still useful offline
+	if len(urls) == 0 {
+		urls = append(urls, "")
+	}
+	var queries []LocalQuery
+	seen := make(map[string]bool)
+	skipped := 0
 	for _, u := range urls {
 		data := ParseURL(u)
-		for _, raw := range templateData {
-			out := RenderTemplate(raw, data)
-			fmt.Println(out)
+		for _, d := range dorks {
+			if !d.HasEngine("github") {
+				continue
+			}
+			rendered, n := githubQueries(d, data)
+			skipped += n
+			for _, query := range rendered {
+				if seen[query] {
+					continue
+				}
+				seen[query] = true
+				if q := ParseLocalQuery(d.Name, query); !q.Empty() {
+					queries = append(queries, q)
+				}
+			}
 		}
 	}
+
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "[WARN] skipped %d dork queries that need a target, use -u to render them\n", skipped)
+	}
+	if len(queries) == 0 {
+		fmt.Fprintf(os.Stderr, "No dork query to evaluate\n")
+		return
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	emit := func(m LocalMatch) {
+		enc.Encode(m)
+	}
+	for _, dir := range splitList(localDirs) {
+		var err error
+		if allCommits {
+			err = ScanGitHistory(dir, queries, emit)
+		} else {
+			err = ScanDir(dir, queries, emit)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] %s: %v\n", dir, err)
+		}
+	}
+}
+
+// splitList split comma separated values
+func splitList(raw string) []string {
+	var results []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			results = append(results, v)
+		}
+	}
+	return results
+}
+
+// RenderStrict render template and fail when a variable is missing
+func RenderStrict(format string, data map[string]string) (string, error) {
+	t, err := template.New("").Option("missingkey=error").Parse(format)
+	if err != nil {
+		return "", err
+	}
+	buf := &bytes.Buffer{}
+	if err := t.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
 }
 
 func RenderTemplate(format string, data map[string]string) string {
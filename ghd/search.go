@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SearchResult is a file found by Github code search
+type SearchResult struct {
+	Dork      string   `json:"dork"`
+	Query     string   `json:"query"`
+	Repo      string   `json:"repo"`
+	Path      string   `json:"path"`
+	HTMLURL   string   `json:"html_url"`
+	Fragments []string `json:"fragments,omitempty"`
+}
+
+type codeSearchResponse struct {
+	TotalCount int `json:"total_count"`
+	Items      []struct {
+		Path       string `json:"path"`
+		HTMLURL    string `json:"html_url"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+		TextMatches []struct {
+			Fragment string `json:"fragment"`
+		} `json:"text_matches"`
+	} `json:"items"`
+}
+
+// Searcher call Github code search API
+type Searcher struct {
+	*GithubClient
+	MaxPages int
+	PerPage  int
+}
+
+// NewSearcher create searcher, code search always need a token
+func NewSearcher(baseURL string, tokens []string) (*Searcher, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("need at least one token via -token or GITHUB_TOKEN")
+	}
+	return &Searcher{
+		GithubClient: NewGithubClient(baseURL, tokens),
+		MaxPages:     10,
+		PerPage:      100,
+	}, nil
+}
+
+// Search page through results of the query and call fn with every result
+func (s *Searcher) Search(dork string, query string, fn func(SearchResult)) error {
+	seen := 0
+	for page := 1; page <= s.MaxPages; page++ {
+		result, err := s.fetchPage(query, page)
+		if err != nil {
+			return err
+		}
+		for _, item := range result.Items {
+			r := SearchResult{
+				Dork:    dork,
+				Query:   query,
+				Repo:    item.Repository.FullName,
+				Path:    item.Path,
+				HTMLURL: item.HTMLURL,
+			}
+			for _, m := range item.TextMatches {
+				r.Fragments = append(r.Fragments, m.Fragment)
+			}
+			fn(r)
+		}
+		seen += len(result.Items)
+		if len(result.Items) < s.PerPage || seen >= result.TotalCount {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *Searcher) fetchPage(query string, page int) (*codeSearchResponse, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("per_page", strconv.Itoa(s.PerPage))
+	params.Set("page", strconv.Itoa(page))
+
+	// text-match media type return the matched fragments
+	status, body, err := s.Get("/search/code", params, "application/vnd.github.text-match+json")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case status == http.StatusOK:
+		var result codeSearchResponse
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %v", err)
+		}
+		return &result, nil
+	case status == http.StatusUnprocessableEntity && page > 1:
+		// over 1000 results
+		return &codeSearchResponse{}, nil
+	}
+	return nil, fmt.Errorf("status %d: %s", status, strings.TrimSpace(string(body)))
+}
+
+// queryFromURL get the query from the old dork format https://github.com/search?q=...
+func queryFromURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("q")
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/chromedp"
+)
+
+// Browser is the only Chrome process of a run
+type Browser struct {
+	// TabSetup run on every new tab
+	TabSetup []chromedp.Action
+
+	ctx         context.Context
+	cancel      context.CancelFunc
+	allocCancel context.CancelFunc
+}
+
+// NewBrowser start Chrome, the temp user data dir is removed by chromedp on Close
+func NewBrowser() (*Browser, error) {
+	// prepare the chrome options
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", headless),
+		chromedp.Flag("ignore-certificate-errors", true),
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("enable-automation", true),
+		chromedp.Flag("disable-extensions", true),
+		chromedp.Flag("disable-setuid-sandbox", true),
+		chromedp.Flag("disable-web-security", true),
+		chromedp.Flag("no-first-run", true),
+		chromedp.Flag("no-default-browser-check", true),
+	)
+	if proxy != "" {
+		opts = append(opts, chromedp.ProxyServer(proxy))
+	}
+
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	ctx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+	// start the browser with the first tab
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		allocCancel()
+		return nil, fmt.Errorf("failed to start chrome: %v", err)
+	}
+	return &Browser{ctx: ctx, cancel: cancel, allocCancel: allocCancel}, nil
+}
+
+// Close the browser and all of its tabs
+func (b *Browser) Close() {
+	b.cancel()
+	b.allocCancel()
+}
+
+// Tab is a browser tab in the pool
+type Tab struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	crashed int32
+
+	mu      sync.Mutex
+	capture *Capture
+}
+
+// setCapture forward network events of the tab to c, nil to stop
+func (t *Tab) setCapture(c *Capture) {
+	t.mu.Lock()
+	t.capture = c
+	t.mu.Unlock()
+}
+
+// NewTab open a new tab in the browser
+func (b *Browser) NewTab() (*Tab, error) {
+	ctx, cancel := chromedp.NewContext(b.ctx)
+	tab := &Tab{ctx: ctx, cancel: cancel}
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		if _, ok := ev.(*inspector.EventTargetCrashed); ok {
+			atomic.StoreInt32(&tab.crashed, 1)
+			return
+		}
+		tab.mu.Lock()
+		c := tab.capture
+		tab.mu.Unlock()
+		if c != nil {
+			c.Handle(ev)
+		}
+	})
+	if err := chromedp.Run(ctx, b.TabSetup...); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to open tab: %v", err)
+	}
+	return tab, nil
+}
+
+// Healthy check if the tab is still usable
+func (t *Tab) Healthy() bool {
+	if atomic.LoadInt32(&t.crashed) == 1 || t.ctx.Err() != nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(t.ctx, 5*time.Second)
+	defer cancel()
+	var res int
+	return chromedp.Run(ctx, chromedp.Evaluate(`1`, &res)) == nil
+}
+
+// TabPool hand out a fixed number of tabs of the browser
+type TabPool struct {
+	browser *Browser
+	tabs    chan *Tab
+	// Timeout apply to each page
+	Timeout time.Duration
+}
+
+// NewTabPool open size tabs
+func NewTabPool(b *Browser, size int, timeout time.Duration) (*TabPool, error) {
+	p := &TabPool{browser: b, tabs: make(chan *Tab, size), Timeout: timeout}
+	for i := 0; i < size; i++ {
+		tab, err := b.NewTab()
+		if err != nil {
+			return nil, err
+		}
+		p.tabs <- tab
+	}
+	return p, nil
+}
+
+// Run the actions in a free tab, the tab is recreated when it crashed
+// network events are recorded to capture when it's not nil
+func (p *TabPool) Run(capture *Capture, actions ...chromedp.Action) error {
+	tab := <-p.tabs
+	tab.setCapture(capture)
+	ctx, cancel := context.WithTimeout(tab.ctx, p.Timeout)
+	err := chromedp.Run(ctx, actions...)
+	cancel()
+	tab.setCapture(nil)
+
+	if err != nil && !tab.Healthy() {
+		tab.cancel()
+		newTab, tabErr := p.browser.NewTab()
+		if tabErr != nil {
+			// keep the pool size, the next run will try again
+			log.Printf("failed to recreate tab: %v", tabErr)
+			newTab = tab
+		} else if verbose {
+			log.Printf("recreated crashed tab")
+		}
+		tab = newTab
+	}
+	p.tabs <- tab
+	return err
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+)
+
+const (
+	CaptureJSONL = "jsonl"
+	CaptureHAR   = "har"
+)
+
+// NetworkEntry is a request made by the page
+type NetworkEntry struct {
+	Page   string `json:"page"`
+	Type   string `json:"type"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Status int64  `json:"status,omitempty"`
+	Mime   string `json:"mime,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	statusText      string
+	requestHeaders  network.Headers
+	responseHeaders network.Headers
+	postData        string
+	size            float64
+	started         time.Time
+	duration        time.Duration
+	sentAt          time.Time
+	loaderID        cdp.LoaderID
+}
+
+// Capture record the network events of a page
+type Capture struct {
+	Page string
+	// Types of resource to keep like xhr, fetch, websocket, script, empty mean all
+	Types map[string]bool
+
+	mu      sync.Mutex
+	started time.Time
+	entries map[network.RequestID]*NetworkEntry
+	order   []*NetworkEntry
+	// committed is set when the navigation of the page commit in the main frame
+	// the tab is reused so the events before that may come from the previous page
+	committed bool
+	// navLoader is the loader of the latest navigation request in the capture
+	navLoader cdp.LoaderID
+}
+
+// NewCapture create capture of a page
+func NewCapture(page string, types []string) *Capture {
+	c := &Capture{
+		Page:    page,
+		Types:   make(map[string]bool),
+		started: time.Now(),
+		entries: make(map[network.RequestID]*NetworkEntry),
+	}
+	for _, t := range types {
+		c.Types[strings.ToLower(t)] = true
+	}
+	return c
+}
+
+func (c *Capture) newEntry(id network.RequestID, typ string, method string, u string) *NetworkEntry {
+	e := &NetworkEntry{Page: c.Page, Type: typ, Method: method, URL: u, started: time.Now()}
+	c.entries[id] = e
+	c.order = append(c.order, e)
+	return e
+}
+
+// Handle a CDP event of the Network domain and the main frame navigation
+func (c *Capture) Handle(ev interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch ev := ev.(type) {
+	case *page.EventFrameNavigated:
+		if !c.committed && ev.Frame.ParentID == "" {
+			c.committed = true
+			c.navLoader = ev.Frame.LoaderID
+			c.keepLoader(ev.Frame.LoaderID)
+		}
+	case *network.EventRequestWillBeSent:
+		// the previous request of the same id is a redirect
+		if prev, ok := c.entries[ev.RequestID]; ok && ev.RedirectResponse != nil {
+			prev.setResponse(ev.RedirectResponse)
+		}
+		e := c.newEntry(ev.RequestID, string(ev.Type), ev.Request.Method, ev.Request.URL+ev.Request.URLFragment)
+		e.loaderID = ev.LoaderID
+		// the request id of a navigation is its loader id
+		if !c.committed && ev.Type == network.ResourceTypeDocument && string(ev.RequestID) == string(ev.LoaderID) {
+			c.navLoader = ev.LoaderID
+		}
+		e.requestHeaders = ev.Request.Headers
+		e.postData = ev.Request.PostData
+		if ev.WallTime != nil {
+			e.started = ev.WallTime.Time()
+		}
+		if ev.Timestamp != nil {
+			e.sentAt = ev.Timestamp.Time()
+		}
+	case *network.EventResponseReceived:
+		if e, ok := c.entries[ev.RequestID]; ok {
+			e.setResponse(ev.Response)
+		}
+	case *network.EventLoadingFinished:
+		if e, ok := c.entries[ev.RequestID]; ok {
+			e.size = ev.EncodedDataLength
+			if ev.Timestamp != nil && !e.sentAt.IsZero() {
+				e.duration = ev.Timestamp.Time().Sub(e.sentAt)
+			}
+		}
+	case *network.EventLoadingFailed:
+		if e, ok := c.entries[ev.RequestID]; ok {
+			e.Error = ev.ErrorText
+		}
+	case *network.EventWebSocketCreated:
+		// the event has no loader id, it belong to the navigation in progress
+		e := c.newEntry(ev.RequestID, string(network.ResourceTypeWebSocket), "GET", ev.URL)
+		e.loaderID = c.navLoader
+	case *network.EventWebSocketWillSendHandshakeRequest:
+		if e, ok := c.entries[ev.RequestID]; ok && ev.Request != nil {
+			e.requestHeaders = ev.Request.Headers
+		}
+	case *network.EventWebSocketHandshakeResponseReceived:
+		if e, ok := c.entries[ev.RequestID]; ok && ev.Response != nil {
+			e.Status = ev.Response.Status
+			e.statusText = ev.Response.StatusText
+			e.responseHeaders = ev.Response.Headers
+		}
+	}
+}
+
+// keepLoader drop the requests that don't belong to the committed navigation
+func (c *Capture) keepLoader(loaderID cdp.LoaderID) {
+	var order []*NetworkEntry
+	for _, e := range c.order {
+		if e.loaderID == loaderID {
+			order = append(order, e)
+		}
+	}
+	for id, e := range c.entries {
+		if e.loaderID != loaderID {
+			delete(c.entries, id)
+		}
+	}
+	c.order = order
+}
+
+func (e *NetworkEntry) setResponse(resp *network.Response) {
+	e.Status = resp.Status
+	e.statusText = resp.StatusText
+	e.Mime = resp.MimeType
+	e.responseHeaders = resp.Headers
+}
+
+// Entries return the recorded requests in order, filtered by Types
+// only the document requests of the navigation are kept when it never committed
+func (c *Capture) Entries() []*NetworkEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var results []*NetworkEntry
+	for _, e := range c.order {
+		if !c.committed && (e.loaderID != c.navLoader || e.Type != string(network.ResourceTypeDocument)) {
+			continue
+		}
+		if len(c.Types) > 0 && !c.Types[strings.ToLower(e.Type)] {
+			continue
+		}
+		results = append(results, e)
+	}
+	return results
+}
+
+// WriteJSONL write one request per line
+func (c *Capture) WriteJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, e := range c.Entries() {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harResponse struct {
+	Status      int64          `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harEntry struct {
+	Pageref         string      `json:"pageref"`
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ResourceType    string      `json:"_resourceType"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harPage struct {
+	StartedDateTime string   `json:"startedDateTime"`
+	ID              string   `json:"id"`
+	Title           string   `json:"title"`
+	PageTimings     struct{} `json:"pageTimings"`
+}
+
+type harLog struct {
+	Log struct {
+		Version string `json:"version"`
+		Creator struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"creator"`
+		Pages   []harPage  `json:"pages"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+func harHeaders(headers network.Headers) []harNameValue {
+	results := []harNameValue{}
+	for k, v := range headers {
+		results = append(results, harNameValue{Name: k, Value: fmt.Sprint(v)})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+func harQuery(raw string) []harNameValue {
+	results := []harNameValue{}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return results
+	}
+	for k, values := range u.Query() {
+		for _, v := range values {
+			results = append(results, harNameValue{Name: k, Value: v})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+// WriteHAR write the page and its requests in HAR 1.2 format
+func (c *Capture) WriteHAR(w io.Writer) error {
+	var har harLog
+	har.Log.Version = "1.2"
+	har.Log.Creator.Name = "oic"
+	har.Log.Creator.Version = "1.0"
+	har.Log.Pages = []harPage{{StartedDateTime: c.started.Format(time.RFC3339Nano), ID: "page_1", Title: c.Page}}
+	har.Log.Entries = []harEntry{}
+
+	for _, e := range c.Entries() {
+		ms := float64(e.duration) / float64(time.Millisecond)
+		entry := harEntry{
+			Pageref:         "page_1",
+			StartedDateTime: e.started.Format(time.RFC3339Nano),
+			Time:            ms,
+			Request: harRequest{
+				Method:      e.Method,
+				URL:         e.URL,
+				HTTPVersion: "HTTP/1.1",
+				Cookies:     []harNameValue{},
+				Headers:     harHeaders(e.requestHeaders),
+				QueryString: harQuery(e.URL),
+				HeadersSize: -1,
+				BodySize:    len(e.postData),
+			},
+			Response: harResponse{
+				Status:      e.Status,
+				StatusText:  e.statusText,
+				HTTPVersion: "HTTP/1.1",
+				Cookies:     []harNameValue{},
+				Headers:     harHeaders(e.responseHeaders),
+				Content:     harContent{Size: int(e.size), MimeType: e.Mime},
+				HeadersSize: -1,
+				BodySize:    int(e.size),
+			},
+			Timings:      harTimings{Send: 0, Wait: ms, Receive: 0},
+			ResourceType: strings.ToLower(e.Type),
+			Error:        e.Error,
+		}
+		if e.postData != "" {
+			mime := ""
+			for k, v := range e.requestHeaders {
+				if strings.EqualFold(k, "Content-Type") {
+					mime = fmt.Sprint(v)
+				}
+			}
+			entry.Request.PostData = &harPostData{MimeType: mime, Text: e.postData}
+		}
+		if loc, ok := e.responseHeaders["Location"]; ok {
+			entry.Response.RedirectURL = fmt.Sprint(loc)
+		}
+		har.Log.Entries = append(har.Log.Entries, entry)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(har)
+}
+
+var unsafeFilenameRE = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// CaptureFilename gen a file name of the page, e.g: https://example.com/a?b -> example.com_a_b-1a2b3c4d.har
+func CaptureFilename(dir string, page string, ext string) string {
+	name := strings.TrimPrefix(strings.TrimPrefix(page, "https://"), "http://")
+	name = strings.Trim(unsafeFilenameRE.ReplaceAllString(name, "_"), "_")
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	sum := sha1.Sum([]byte(page))
+	return filepath.Join(dir, fmt.Sprintf("%s-%x.%s", name, sum[:4], ext))
+}
+
+var stdoutMu sync.Mutex
+
+// SaveCapture write the capture to a file per page, JSONL go to stdout when there is no output dir
+func SaveCapture(c *Capture, format string, dir string) error {
+	if format == CaptureJSONL && dir == "" {
+		stdoutMu.Lock()
+		defer stdoutMu.Unlock()
+		return c.WriteJSONL(os.Stdout)
+	}
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(CaptureFilename(dir, c.Page, format))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if format == CaptureHAR {
+		return c.WriteHAR(f)
+	}
+	return c.WriteJSONL(f)
+}
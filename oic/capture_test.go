@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+)
+
+func requestEvent(id string, loader string, typ network.ResourceType, method string, u string) *network.EventRequestWillBeSent {
+	return &network.EventRequestWillBeSent{
+		RequestID: network.RequestID(id),
+		LoaderID:  cdp.LoaderID(loader),
+		Type:      typ,
+		Request:   &network.Request{Method: method, URL: u, Headers: network.Headers{}},
+	}
+}
+
+func responseEvent(id string, status int64, mime string) *network.EventResponseReceived {
+	return &network.EventResponseReceived{
+		RequestID: network.RequestID(id),
+		Response:  &network.Response{Status: status, MimeType: mime, Headers: network.Headers{}},
+	}
+}
+
+func commitEvent(loader string) *page.EventFrameNavigated {
+	return &page.EventFrameNavigated{Frame: &cdp.Frame{ID: "main", LoaderID: cdp.LoaderID(loader)}}
+}
+
+type capturedEntry struct {
+	Type   string
+	URL    string
+	Status int64
+}
+
+func captured(c *Capture) []capturedEntry {
+	var results []capturedEntry
+	for _, e := range c.Entries() {
+		results = append(results, capturedEntry{e.Type, e.URL, e.Status})
+	}
+	return results
+}
+
+func TestCaptureHandle(t *testing.T) {
+	tests := []struct {
+		name   string
+		types  []string
+		events []interface{}
+		want   []capturedEntry
+	}{
+		{
+			name: "redirect chain with one request id",
+			events: []interface{}{
+				requestEvent("L", "L", network.ResourceTypeDocument, "GET", "http://a.com/"),
+				func() interface{} {
+					ev := requestEvent("L", "L", network.ResourceTypeDocument, "GET", "https://a.com/")
+					ev.RedirectResponse = &network.Response{Status: 301, Headers: network.Headers{"Location": "https://a.com/"}}
+					return ev
+				}(),
+				commitEvent("L"),
+				responseEvent("L", 200, "text/html"),
+			},
+			want: []capturedEntry{
+				{"Document", "http://a.com/", 301},
+				{"Document", "https://a.com/", 200},
+			},
+		},
+		{
+			name: "events of the previous page before commit",
+			events: []interface{}{
+				requestEvent("o1", "old", network.ResourceTypeXHR, "GET", "https://old.com/poll"),
+				&network.EventWebSocketCreated{RequestID: "ows", URL: "wss://old.com/live"},
+				requestEvent("L", "L", network.ResourceTypeDocument, "GET", "https://a.com/"),
+				commitEvent("L"),
+				// late response of the old page is ignored
+				responseEvent("o1", 200, "application/json"),
+				requestEvent("s1", "L", network.ResourceTypeScript, "GET", "https://cdn.com/app.js"),
+				// iframe of the new page has its own loader
+				requestEvent("f1", "F", network.ResourceTypeXHR, "POST", "https://frame.com/api"),
+			},
+			want: []capturedEntry{
+				{"Document", "https://a.com/", 0},
+				{"Script", "https://cdn.com/app.js", 0},
+				{"XHR", "https://frame.com/api", 0},
+			},
+		},
+		{
+			name: "only the commit of the main frame reset the capture",
+			events: []interface{}{
+				requestEvent("L", "L", network.ResourceTypeDocument, "GET", "https://a.com/"),
+				&page.EventFrameNavigated{Frame: &cdp.Frame{ID: "child", ParentID: "main", LoaderID: "F"}},
+				commitEvent("L"),
+				requestEvent("x1", "L", network.ResourceTypeXHR, "GET", "https://a.com/api"),
+				// a later navigation of the page is kept
+				requestEvent("L2", "L2", network.ResourceTypeDocument, "GET", "https://a.com/next"),
+				commitEvent("L2"),
+			},
+			want: []capturedEntry{
+				{"Document", "https://a.com/", 0},
+				{"XHR", "https://a.com/api", 0},
+				{"Document", "https://a.com/next", 0},
+			},
+		},
+		{
+			name: "navigation never committed",
+			events: []interface{}{
+				requestEvent("O", "O", network.ResourceTypeDocument, "GET", "https://old.com/"),
+				requestEvent("o1", "O", network.ResourceTypeXHR, "GET", "https://old.com/api"),
+				requestEvent("L", "L", network.ResourceTypeDocument, "GET", "https://down.com/"),
+				&network.EventLoadingFailed{RequestID: "L", ErrorText: "net::ERR_NAME_NOT_RESOLVED"},
+			},
+			want: []capturedEntry{
+				{"Document", "https://down.com/", 0},
+			},
+		},
+		{
+			name: "websocket of the navigation",
+			events: []interface{}{
+				requestEvent("L", "L", network.ResourceTypeDocument, "GET", "https://a.com/"),
+				&network.EventWebSocketCreated{RequestID: "ws", URL: "wss://a.com/live"},
+				commitEvent("L"),
+				&network.EventWebSocketHandshakeResponseReceived{RequestID: "ws", Response: &network.WebSocketResponse{Status: 101, StatusText: "Switching Protocols"}},
+			},
+			want: []capturedEntry{
+				{"Document", "https://a.com/", 0},
+				{"WebSocket", "wss://a.com/live", 101},
+			},
+		},
+		{
+			name:  "capture types",
+			types: []string{"xhr", "WebSocket"},
+			events: []interface{}{
+				requestEvent("L", "L", network.ResourceTypeDocument, "GET", "https://a.com/"),
+				commitEvent("L"),
+				requestEvent("s1", "L", network.ResourceTypeScript, "GET", "https://a.com/app.js"),
+				requestEvent("x1", "L", network.ResourceTypeXHR, "GET", "https://a.com/api"),
+				requestEvent("f1", "L", network.ResourceTypeFetch, "GET", "https://a.com/fetch"),
+				&network.EventWebSocketCreated{RequestID: "ws", URL: "wss://a.com/live"},
+			},
+			want: []capturedEntry{
+				{"XHR", "https://a.com/api", 0},
+				{"WebSocket", "wss://a.com/live", 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCapture("https://a.com/", tt.types)
+			for _, ev := range tt.events {
+				c.Handle(ev)
+			}
+			if got := captured(c); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entries = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaptureWriteJSONL(t *testing.T) {
+	c := NewCapture("https://a.com/", nil)
+	c.Handle(requestEvent("L", "L", network.ResourceTypeDocument, "GET", "https://a.com/?a=1&b=2"))
+	c.Handle(commitEvent("L"))
+	c.Handle(responseEvent("L", 200, "text/html"))
+
+	buf := &bytes.Buffer{}
+	if err := c.WriteJSONL(buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"page":"https://a.com/","type":"Document","method":"GET","url":"https://a.com/?a=1&b=2","status":200,"mime":"text/html"}`
+	if got := strings.TrimSpace(buf.String()); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestCaptureWriteHAR(t *testing.T) {
+	c := NewCapture("https://a.com/", nil)
+	c.Handle(requestEvent("L", "L", network.ResourceTypeDocument, "GET", "http://a.com/"))
+	redirect := requestEvent("L", "L", network.ResourceTypeDocument, "GET", "https://a.com/")
+	redirect.RedirectResponse = &network.Response{Status: 301, StatusText: "Moved Permanently", Headers: network.Headers{"Location": "https://a.com/"}}
+	c.Handle(redirect)
+	c.Handle(commitEvent("L"))
+	c.Handle(responseEvent("L", 200, "text/html"))
+	post := requestEvent("x1", "L", network.ResourceTypeXHR, "POST", "https://a.com/api?z=2&id=1")
+	post.Request.Headers = network.Headers{"X-Token": "t", "Content-Type": "application/json"}
+	post.Request.PostData = `{"q":1}`
+	c.Handle(post)
+	c.Handle(&network.EventLoadingFailed{RequestID: "x1", ErrorText: "net::ERR_FAILED"})
+
+	buf := &bytes.Buffer{}
+	if err := c.WriteHAR(buf); err != nil {
+		t.Fatal(err)
+	}
+	var har harLog
+	if err := json.Unmarshal(buf.Bytes(), &har); err != nil {
+		t.Fatalf("invalid HAR: %v", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Pages) != 1 || har.Log.Pages[0].Title != "https://a.com/" {
+		t.Fatalf("invalid HAR log: %+v", har.Log)
+	}
+	if len(har.Log.Entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(har.Log.Entries))
+	}
+
+	moved := har.Log.Entries[0]
+	if moved.Response.Status != 301 || moved.Response.RedirectURL != "https://a.com/" || moved.ResourceType != "document" {
+		t.Errorf("invalid redirect entry: %+v", moved)
+	}
+	if ok := har.Log.Entries[1]; ok.Response.Status != 200 || ok.Response.Content.MimeType != "text/html" {
+		t.Errorf("invalid document entry: %+v", ok)
+	}
+
+	api := har.Log.Entries[2]
+	wantHeaders := []harNameValue{{"Content-Type", "application/json"}, {"X-Token", "t"}}
+	if !reflect.DeepEqual(api.Request.Headers, wantHeaders) {
+		t.Errorf("headers = %v, want %v", api.Request.Headers, wantHeaders)
+	}
+	wantQuery := []harNameValue{{"id", "1"}, {"z", "2"}}
+	if !reflect.DeepEqual(api.Request.QueryString, wantQuery) {
+		t.Errorf("query = %v, want %v", api.Request.QueryString, wantQuery)
+	}
+	if api.Request.PostData == nil || api.Request.PostData.MimeType != "application/json" || api.Request.PostData.Text != `{"q":1}` {
+		t.Errorf("invalid post data: %+v", api.Request.PostData)
+	}
+	if api.Error != "net::ERR_FAILED" || api.Pageref != "page_1" {
+		t.Errorf("invalid api entry: %+v", api)
+	}
+}
@@ -3,6 +3,7 @@ module github.com/j3ssie/go-auxs/oic
 go 1.16
 
 require (
+	github.com/chromedp/cdproto v0.0.0-20210526005521-9e51b9051fd0
 	github.com/chromedp/chromedp v0.7.3
 	github.com/skratchdot/open-golang v0.0.0-20200116055534-eef842397966
 )
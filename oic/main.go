@@ -2,13 +2,11 @@ package main
 
 import (
 	"bufio"
-	"context"
 	"flag"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
 	"log"
 	"os"
-	"path"
-	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -28,8 +26,25 @@ var (
 	data        string
 	dataFile    string
 	proxy       string
+	pool        *TabPool
+
+	captureFormat string
+	captureTypes  []string
+	outputDir     string
 )
 
+// arrayFlags allow a flag to be repeated
+type arrayFlags []string
+
+func (a *arrayFlags) String() string {
+	return strings.Join(*a, ",")
+}
+
+func (a *arrayFlags) Set(value string) error {
+	*a = append(*a, value)
+	return nil
+}
+
 func main() {
 	// cli args
 	flag.StringVar(&data, "u", "", "URL to open")
@@ -38,8 +53,53 @@ func main() {
 	flag.IntVar(&timeout, "t", 15, "timeout in second")
 	flag.StringVar(&proxy, "proxy", "", "proxy to pass chrome to (eg: http://127.0.0.1:8080)")
 	flag.BoolVar(&headless, "q", false, "enable headless")
+	var cookieFile, basicAuth, loginScript string
+	var headers arrayFlags
+	session := &Session{}
+	flag.StringVar(&cookieFile, "cookie", "", "cookies file in Netscape cookies.txt or JSON format")
+	flag.Var(&headers, "H", "extra header in format 'Name: value' (can be repeated)")
+	flag.StringVar(&basicAuth, "auth", "", "basic auth in format user:pass")
+	flag.StringVar(&session.LoginURL, "login", "", "login URL to open before any URL")
+	flag.StringVar(&loginScript, "login-script", "", "javascript file to run on the login URL (eg: fill the form and submit)")
+	flag.DurationVar(&session.LoginWait, "login-wait", 5*time.Second, "time to wait for the login to finish")
+	var rawTypes string
+	flag.StringVar(&captureFormat, "capture", "", "capture network requests of each page (jsonl, har)")
+	flag.StringVar(&rawTypes, "capture-types", "", "only capture these resource types (eg: xhr,fetch,websocket,script), default is all")
+	flag.StringVar(&outputDir, "o", "", "output dir of the captures, jsonl is printed to stdout when empty")
 	flag.Parse()
 
+	if captureFormat != "" && captureFormat != CaptureJSONL && captureFormat != CaptureHAR {
+		log.Fatalf("invalid capture format: %s", captureFormat)
+	}
+	for _, t := range strings.Split(rawTypes, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			captureTypes = append(captureTypes, t)
+		}
+	}
+
+	if cookieFile != "" {
+		if err := session.LoadCookies(cookieFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, h := range headers {
+		if err := session.AddHeader(h); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if basicAuth != "" {
+		if err := session.SetBasicAuth(basicAuth); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if loginScript != "" {
+		content, err := os.ReadFile(loginScript)
+		if err != nil {
+			log.Fatal(err)
+		}
+		session.LoginScript = string(content)
+	}
+
 	// detect if anything came from std
 	var inputs []string
 	stat, _ := os.Stdin.Stat()
@@ -63,9 +123,35 @@ func main() {
 	if (stat.Mode()&os.ModeCharDevice) != 0 && len(inputs) == 0 {
 		args := os.Args[1:]
 		sort.Strings(args)
-		raw := args[len(args)-1]
-		RequestWithChrome(raw)
-		os.Exit(0)
+		inputs = append(inputs, args[len(args)-1])
+		concurrency = 1
+	}
+
+	// headers and basic auth are only sent to the targets and the login URL
+	for _, raw := range inputs {
+		session.AddTarget(raw)
+	}
+	session.AddTarget(session.LoginURL)
+
+	// one browser for the whole run, each worker use a tab of the pool
+	browser, err := NewBrowser()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer browser.Close()
+	if captureFormat != "" {
+		browser.TabSetup = append(browser.TabSetup, network.Enable())
+	}
+	if !session.Empty() {
+		if err := session.Apply(browser); err != nil {
+			browser.Close()
+			log.Fatal(err)
+		}
+	}
+	pool, err = NewTabPool(browser, concurrency, time.Duration(timeout)*time.Second)
+	if err != nil {
+		browser.Close()
+		log.Fatal(err)
 	}
 
 	var wg sync.WaitGroup
@@ -90,62 +176,30 @@ func main() {
 	wg.Wait()
 }
 
-// RequestWithChrome Do request with real browser
+// RequestWithChrome Do request with a tab of the shared browser
 func RequestWithChrome(url string) string {
-	// prepare the chrome options
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", headless),
-		chromedp.Flag("ignore-certificate-errors", true),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("enable-automation", true),
-		chromedp.Flag("disable-extensions", true),
-		chromedp.Flag("disable-setuid-sandbox", true),
-		chromedp.Flag("disable-web-security", true),
-		chromedp.Flag("no-first-run", true),
-		chromedp.Flag("no-default-browser-check", true),
-		//chromedp.UserDataDir(""),
-	)
-
-	if proxy != "" {
-		opts = append(opts, chromedp.ProxyServer(proxy))
-	}
-
-	allocCtx, bcancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	defer bcancel()
-
-	ctx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
-	ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
-	defer cancel()
-
 	// run task list
 	var data string
+	var capture *Capture
+	if captureFormat != "" {
+		capture = NewCapture(url, captureTypes)
+	}
 	contentID := "main"
-	err := chromedp.Run(ctx,
+	err := pool.Run(capture,
 		chromedp.Navigate(url),
 		chromedp.OuterHTML(contentID, &data, chromedp.NodeVisible, chromedp.ByID),
 	)
-
-	cleanUp()
+	if capture != nil {
+		if saveErr := SaveCapture(capture, captureFormat, outputDir); saveErr != nil {
+			log.Printf("failed to save capture of %s: %v", url, saveErr)
+		}
+	}
 	if err != nil {
 		return ""
 	}
 	return data
 }
 
-func cleanUp() {
-	tmpFolder := path.Join(os.TempDir(), "chromedp-runner*")
-	if _, err := os.Stat("/tmp/"); !os.IsNotExist(err) {
-		tmpFolder = path.Join("/tmp/", "chromedp-runner*")
-	}
-	junks, err := filepath.Glob(tmpFolder)
-	if err != nil {
-		return
-	}
-	for _, junk := range junks {
-		os.RemoveAll(junk)
-	}
-}
-
 // ReadingLines Reading file and return content as []string
 func ReadingLines(filename string) []string {
 	var result []string
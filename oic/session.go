@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// Session is the authenticated state shared by all tabs
+// headers and basic auth are only sent to the origins of the targets, not to third party requests
+type Session struct {
+	Cookies  []*network.CookieParam
+	Headers  network.Headers
+	Username string
+	Password string
+	// Origins like https://example.com that get the headers and basic auth
+	Origins map[string]bool
+	// LoginURL is opened before any URL, LoginScript is evaluated there
+	LoginURL    string
+	LoginScript string
+	LoginWait   time.Duration
+}
+
+// Empty is true when there is nothing to inject
+func (s *Session) Empty() bool {
+	return len(s.Cookies) == 0 && len(s.Headers) == 0 && s.Username == "" && s.LoginURL == ""
+}
+
+// AddTarget allow the origin of the target URL to get the headers and basic auth
+func (s *Session) AddTarget(raw string) {
+	origin := originOf(raw)
+	if origin == "" {
+		return
+	}
+	if s.Origins == nil {
+		s.Origins = make(map[string]bool)
+	}
+	s.Origins[origin] = true
+}
+
+// InScope check if the request URL belong to one of the targets
+func (s *Session) InScope(raw string) bool {
+	return s.Origins[originOf(raw)]
+}
+
+// originOf return scheme://host with the default port removed, empty when URL is invalid
+func originOf(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	port := u.Port()
+	if port != "" && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	return scheme + "://" + host
+}
+
+// AddHeader parse header in format: Name: value
+func (s *Session) AddHeader(raw string) error {
+	parts := strings.SplitN(raw, ":", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return fmt.Errorf("invalid header %s, expected Name: value", raw)
+	}
+	if s.Headers == nil {
+		s.Headers = make(network.Headers)
+	}
+	s.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	return nil
+}
+
+// SetBasicAuth answer the auth challenges of the targets with user:pass
+func (s *Session) SetBasicAuth(userPass string) error {
+	parts := strings.SplitN(userPass, ":", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid basic auth, expected user:pass")
+	}
+	s.Username, s.Password = parts[0], parts[1]
+	return nil
+}
+
+// LoadCookies load Netscape cookies.txt or JSON cookies exported by browser extensions
+func (s *Session) LoadCookies(filename string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var cookies []*network.CookieParam
+	trimmed := strings.TrimSpace(string(content))
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		cookies, err = parseJSONCookies([]byte(trimmed))
+	} else {
+		cookies, err = parseNetscapeCookies(trimmed)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse cookies %s: %v", filename, err)
+	}
+	s.Cookies = append(s.Cookies, cookies...)
+	return nil
+}
+
+// parseNetscapeCookies domain, include subdomains, path, secure, expires, name, value
+func parseNetscapeCookies(content string) ([]*network.CookieParam, error) {
+	var cookies []*network.CookieParam
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		httpOnly := false
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+			httpOnly = true
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			return nil, fmt.Errorf("invalid line: %s", line)
+		}
+		c := &network.CookieParam{
+			Domain:   fields[0],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HTTPOnly: httpOnly,
+		}
+		if expires, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expires > 0 {
+			t := cdp.TimeSinceEpoch(time.Unix(expires, 0))
+			c.Expires = &t
+		}
+		cookies = append(cookies, c)
+	}
+	return cookies, sc.Err()
+}
+
+// jsonCookie cover the format of EditThisCookie, Cookie-Editor and Playwright
+type jsonCookie struct {
+	Name           string  `json:"name"`
+	Value          string  `json:"value"`
+	Domain         string  `json:"domain"`
+	Path           string  `json:"path"`
+	Secure         bool    `json:"secure"`
+	HTTPOnly       bool    `json:"httpOnly"`
+	SameSite       string  `json:"sameSite"`
+	ExpirationDate float64 `json:"expirationDate"`
+	Expires        float64 `json:"expires"`
+}
+
+func parseJSONCookies(content []byte) ([]*network.CookieParam, error) {
+	var raw []jsonCookie
+	if content[0] == '{' {
+		// Playwright storage state
+		var state struct {
+			Cookies []jsonCookie `json:"cookies"`
+		}
+		if err := json.Unmarshal(content, &state); err != nil {
+			return nil, err
+		}
+		raw = state.Cookies
+	} else if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	var cookies []*network.CookieParam
+	for _, r := range raw {
+		c := &network.CookieParam{
+			Name:     r.Name,
+			Value:    r.Value,
+			Domain:   r.Domain,
+			Path:     r.Path,
+			Secure:   r.Secure,
+			HTTPOnly: r.HTTPOnly,
+		}
+		switch strings.ToLower(r.SameSite) {
+		case "strict":
+			c.SameSite = network.CookieSameSiteStrict
+		case "lax":
+			c.SameSite = network.CookieSameSiteLax
+		case "none", "no_restriction":
+			c.SameSite = network.CookieSameSiteNone
+		}
+		expires := r.ExpirationDate
+		if expires == 0 {
+			expires = r.Expires
+		}
+		if expires > 0 {
+			t := cdp.TimeSinceEpoch(time.Unix(int64(expires), 0))
+			c.Expires = &t
+		}
+		if c.Path == "" {
+			c.Path = "/"
+		}
+		cookies = append(cookies, c)
+	}
+	return cookies, nil
+}
+
+// TabActions intercept the requests of a new tab to add the headers and answer the auth challenges
+func (s *Session) TabActions() []chromedp.Action {
+	actions := []chromedp.Action{network.Enable()}
+	if len(s.Headers) == 0 && s.Username == "" {
+		return actions
+	}
+	intercept := chromedp.ActionFunc(func(ctx context.Context) error {
+		chromedp.ListenTarget(ctx, func(ev interface{}) {
+			switch ev := ev.(type) {
+			case *fetch.EventRequestPaused:
+				// can't call CDP in the listener, it blocks the event loop
+				go func() {
+					if err := s.continueRequest(ev).Do(ctx); err != nil && verbose {
+						log.Printf("failed to continue request %s: %v", ev.Request.URL, err)
+					}
+				}()
+			case *fetch.EventAuthRequired:
+				go func() {
+					if err := fetch.ContinueWithAuth(ev.RequestID, s.authResponse(ev)).Do(ctx); err != nil && verbose {
+						log.Printf("failed to answer auth of %s: %v", ev.Request.URL, err)
+					}
+				}()
+			}
+		})
+		return fetch.Enable().WithHandleAuthRequests(s.Username != "").Do(ctx)
+	})
+	return append(actions, intercept)
+}
+
+// continueRequest add the headers when the request go to a target
+func (s *Session) continueRequest(ev *fetch.EventRequestPaused) *fetch.ContinueRequestParams {
+	params := fetch.ContinueRequest(ev.RequestID)
+	if len(s.Headers) == 0 || !s.InScope(ev.Request.URL) {
+		return params
+	}
+	merged := make(map[string]string)
+	var names []string
+	for k, v := range ev.Request.Headers {
+		merged[strings.ToLower(k)] = fmt.Sprint(v)
+		names = append(names, k)
+	}
+	for k, v := range s.Headers {
+		if _, ok := merged[strings.ToLower(k)]; !ok {
+			names = append(names, k)
+		}
+		merged[strings.ToLower(k)] = fmt.Sprint(v)
+	}
+	var headers []*fetch.HeaderEntry
+	for _, name := range names {
+		headers = append(headers, &fetch.HeaderEntry{Name: name, Value: merged[strings.ToLower(name)]})
+	}
+	return params.WithHeaders(headers)
+}
+
+// authResponse only give the credentials to the targets, not to proxies or other origins
+func (s *Session) authResponse(ev *fetch.EventAuthRequired) *fetch.AuthChallengeResponse {
+	c := ev.AuthChallenge
+	if s.Username == "" || c == nil || c.Source == fetch.AuthChallengeSourceProxy || !s.InScope(c.Origin) {
+		return &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseDefault}
+	}
+	return &fetch.AuthChallengeResponse{
+		Response: fetch.AuthChallengeResponseResponseProvideCredentials,
+		Username: s.Username,
+		Password: s.Password,
+	}
+}
+
+// Apply set cookies in the browser then run the login, all tabs share the cookie jar of the browser
+func (s *Session) Apply(b *Browser) error {
+	b.TabSetup = append(b.TabSetup, s.TabActions()...)
+	actions := append([]chromedp.Action{}, b.TabSetup...)
+	if len(s.Cookies) > 0 {
+		actions = append(actions, network.SetCookies(s.Cookies))
+	}
+	if err := chromedp.Run(b.ctx, actions...); err != nil {
+		return fmt.Errorf("failed to set session: %v", err)
+	}
+	if s.LoginURL == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(b.ctx, s.LoginWait+time.Minute)
+	defer cancel()
+	login := []chromedp.Action{chromedp.Navigate(s.LoginURL)}
+	if s.LoginScript != "" {
+		var res interface{}
+		login = append(login, chromedp.Evaluate(s.LoginScript, &res))
+	}
+	// wait for the login to finish, or to login by hand when the browser is not headless
+	login = append(login, chromedp.Sleep(s.LoginWait))
+	if err := chromedp.Run(ctx, login...); err != nil {
+		return fmt.Errorf("failed to login: %v", err)
+	}
+	return nil
+}
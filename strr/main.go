@@ -9,12 +9,21 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"text/template"
 )
 
 // Examples
 // echo 'domain.com' | strr -t '{}.{{.Raw}}' -I wordlists.txt
 // echo 'www-{}.domain.com' | strr -I wordlists.txt
 // cat domains.txt | strr -t '{}.{{.Raw}}' -i 'dev'
+// echo 'https://www.domain.com/path' | strr -t '{{.Scheme}}://{{.Word}}.{{.Domain}}' -i 'dev'
+// echo 'domain.com' | strr -t '{svc}-{env}.{{.Raw}}' -w env=envs.txt -w svc=services.txt
+// echo 'domain.com' | strr -t '{svc}-{env}.{{.Raw}}' -w env=envs.txt -w svc=services.txt -mode zip
+// subdomain permutations
+// cat subdomains.txt | strr -perm -I words.txt -depth 2
+// mask brute-force
+// echo 'domain.com' | strr -t 'dev{d:01-20}.{{.Raw}}'
+// echo 'domain.com' | strr -t '{?l?l?d}.{{.Raw}}' -skip 1000 -limit 500
 
 var (
 	verbose        bool
@@ -24,8 +33,32 @@ var (
 	inputList      string
 	replaceString  string
 	templateString string
+	tmpl           *template.Template
+	mode           string
+	wordlists      []Wordlist
+	permMode       bool
+	permDepth      int
+	numRange       int
+	tmplMasks      []Mask
+	lineMasks      bool
+	// skipLeft and limitLeft are shared by every output, limitLeft < 0 mean no limit
+	skipLeft  int64
+	limitLeft int64
+	outMu     sync.Mutex
 )
 
+// arrayFlags allow a flag to be repeated
+type arrayFlags []string
+
+func (a *arrayFlags) String() string {
+	return strings.Join(*a, ",")
+}
+
+func (a *arrayFlags) Set(value string) error {
+	*a = append(*a, value)
+	return nil
+}
+
 func main() {
 	// cli arguments
 	var inputs []string
@@ -34,9 +67,57 @@ func main() {
 	flag.StringVar(&inputList, "I", "", "inputList")
 	flag.StringVar(&input, "i", "", "inputList")
 	flag.StringVar(&replaceString, "s", "{}", "replaceString")
-	flag.StringVar(&templateString, "t", "", "templateString")
+	flag.StringVar(&templateString, "t", "", "Go template, available variables: Raw, Word, Scheme, Domain, Org, Host, Port, Path, RawQuery, BaseURL, Extension\n  helpers: lower, upper, replace, split, trim")
+	var rawWordlists arrayFlags
+	flag.Var(&rawWordlists, "w", "Wordlist bound to a placeholder in format name=file, used as {name} (can be repeated)")
+	flag.StringVar(&mode, "mode", ModeCartesian, "How to combine wordlists of -w (cartesian, zip)")
+	flag.BoolVar(&permMode, "perm", false, "Subdomain permutation mode, words come from -i/-I and subdomains from stdin")
+	flag.IntVar(&permDepth, "depth", 1, "How many times the permutations are applied again on the results")
+	flag.IntVar(&numRange, "nr", 1, "How far to increase/decrease numbers in permutation (api2 -> api1, api3)")
+	flag.BoolVar(&lineMasks, "m", false, "Also expand masks like {d:1-10} in input lines, masks in -t are always expanded")
+	flag.Int64Var(&skipLeft, "skip", 0, "Skip the first N outputs, use with -limit to resume")
+	flag.Int64Var(&limitLeft, "limit", -1, "Stop after N outputs")
+	var warnThreshold int64
+	flag.Int64Var(&warnThreshold, "warn", 10000000, "Warn when the mask keyspace is bigger than this")
+	var countOnly bool
+	flag.BoolVar(&countOnly, "count", false, "Only print the mask keyspace of -t then exit")
 	flag.Parse()
 
+	if mode != ModeCartesian && mode != ModeZip {
+		fmt.Fprintf(os.Stderr, "invalid mode: %s\n", mode)
+		os.Exit(-1)
+	}
+	for _, raw := range rawWordlists {
+		w, err := ParseWordlist(raw)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(-1)
+		}
+		wordlists = append(wordlists, w)
+	}
+
+	if templateString != "" {
+		format, masks, err := ExtractMasks(templateString, true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to parse mask: %v\n", err)
+			os.Exit(-1)
+		}
+		tmplMasks = masks
+		if countOnly {
+			fmt.Println(Keyspace(tmplMasks))
+			return
+		}
+		if ks := Keyspace(tmplMasks); len(tmplMasks) > 0 && ks > warnThreshold {
+			fmt.Fprintf(os.Stderr, "[WARN] mask keyspace is %d for each input line\n", ks)
+		}
+		t, err := ParseTemplate(format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to parse template: %v\n", err)
+			os.Exit(-1)
+		}
+		tmpl = t
+	}
+
 	stat, _ := os.Stdin.Stat()
 	if (stat.Mode() & os.ModeCharDevice) != 0 {
 		args := os.Args[1:]
@@ -55,6 +136,31 @@ func main() {
 		inputs = append(inputs, ReadingLines(inputList)...)
 	}
 
+	if permMode {
+		var hosts []string
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line != "" {
+				hosts = append(hosts, line)
+			}
+		}
+		p := &Permutator{Words: inputs, Depth: permDepth, NumRange: numRange}
+		for _, name := range p.Permutate(hosts) {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	// wordlists of -w or masks alone is enough
+	if len(inputs) == 0 && (len(wordlists) > 0 || len(tmplMasks) > 0 || (lineMasks && tmpl == nil)) {
+		inputs = append(inputs, "")
+	}
+	// the order of output must be stable to resume with -skip
+	if skipLeft > 0 || limitLeft >= 0 {
+		concurrency = 1
+	}
+
 	var wg sync.WaitGroup
 	jobs := make(chan string, concurrency)
 
@@ -64,7 +170,12 @@ func main() {
 			defer wg.Done()
 			for job := range jobs {
 				for _, input := range inputs {
-					doReplace(job, input)
+					err := Combine(mode, wordlists, func(values map[string]string) bool {
+						return generate(job, input, values)
+					})
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "failed to read wordlist: %v\n", err)
+					}
 				}
 			}
 		}()
@@ -83,21 +194,81 @@ func main() {
 	wg.Wait()
 }
 
-func doReplace(raw string, replace string) {
-	if templateString != "" {
-		new := strings.ReplaceAll(templateString, "{{.Raw}}", raw)
-		new = strings.ReplaceAll(new, replaceString, replace)
-		fmt.Println(new)
-		return
+// generate expand the masks of the line or template then print every result
+func generate(raw string, replace string, values map[string]string) bool {
+	var data map[string]string
+	masks := tmplMasks
+	if tmpl != nil {
+		data = ParseURL(raw)
+	} else if lineMasks {
+		line, found, err := ExtractMasks(raw, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to parse mask in %s: %v\n", raw, err)
+			return true
+		}
+		raw, masks = line, found
+	}
+	if len(masks) == 0 {
+		return doReplace(raw, replace, values, data)
 	}
 
+	merged := make(map[string]string)
+	for k, v := range values {
+		merged[k] = v
+	}
+	return EnumerateMasks(masks, func(maskValues []string) bool {
+		for i, v := range maskValues {
+			merged[maskName(i)] = v
+		}
+		return doReplace(raw, replace, merged, data)
+	})
+}
+
+// doReplace print the result, return false when -limit is reached
+func doReplace(raw string, replace string, values map[string]string, data map[string]string) bool {
+	if tmpl != nil {
+		if data == nil {
+			data = ParseURL(raw)
+		}
+		data["Word"] = replace
+		for k, v := range values {
+			data[k] = v
+		}
+		new, err := RenderTemplate(tmpl, data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to render %s: %v\n", raw, err)
+			return true
+		}
+		return emit(new)
+	}
+
+	for k, v := range values {
+		raw = strings.ReplaceAll(raw, "{"+k+"}", v)
+	}
 	if strings.Contains(raw, replaceString) {
 		new := strings.ReplaceAll(raw, replaceString, replace)
-		fmt.Println(new)
-		return
+		return emit(new)
 	}
 
-	fmt.Printf("%s%s\n", raw, replace)
+	return emit(raw + replace)
+}
+
+// emit print the output with -skip and -limit applied
+func emit(s string) bool {
+	outMu.Lock()
+	defer outMu.Unlock()
+	if limitLeft == 0 {
+		return false
+	}
+	if skipLeft > 0 {
+		skipLeft--
+		return true
+	}
+	fmt.Println(s)
+	if limitLeft > 0 {
+		limitLeft--
+	}
+	return limitLeft != 0
 }
 
 // FileExists check if file is exist or not
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Mask is a placeholder that gen values by index, e.g: {d:1-100}, {a-z:3}, {hex:4}, {?l?l?d}
+type Mask interface {
+	Size() int64
+	Value(i int64) string
+}
+
+// named charsets for {name:length}
+var charsets = map[string]string{
+	"digit": "0123456789",
+	"lower": "abcdefghijklmnopqrstuvwxyz",
+	"upper": "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"alpha": "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"alnum": "abcdefghijklmnopqrstuvwxyz0123456789",
+	"hex":   "0123456789abcdef",
+	"HEX":   "0123456789ABCDEF",
+}
+
+// hashcat style charsets for {?l?d}
+var hashcatCharsets = map[byte]string{
+	'l': charsets["lower"],
+	'u': charsets["upper"],
+	'd': charsets["digit"],
+	'h': charsets["hex"],
+	'H': charsets["HEX"],
+	's': " !\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~",
+}
+
+var maskRE = regexp.MustCompile(`\{(d:[0-9]+-[0-9]+|[^{}:\s]+:[0-9]+|\?[^{}\s]+)\}`)
+
+// RangeMask is numeric range, zero-padded when the start has leading zero like {d:01-99}
+type RangeMask struct {
+	Start int64
+	End   int64
+	Width int
+}
+
+func (m RangeMask) Size() int64 {
+	return m.End - m.Start + 1
+}
+
+func (m RangeMask) Value(i int64) string {
+	return fmt.Sprintf("%0*d", m.Width, m.Start+i)
+}
+
+// CharsetMask is a fixed length string, each position has its own charset
+type CharsetMask struct {
+	Positions []string
+}
+
+func (m CharsetMask) Size() int64 {
+	size := int64(1)
+	for _, p := range m.Positions {
+		size = mulSaturate(size, int64(len(p)))
+	}
+	return size
+}
+
+// Value decode index like a number, the last position change fastest
+func (m CharsetMask) Value(i int64) string {
+	b := make([]byte, len(m.Positions))
+	for j := len(m.Positions) - 1; j >= 0; j-- {
+		cs := m.Positions[j]
+		b[j] = cs[i%int64(len(cs))]
+		i /= int64(len(cs))
+	}
+	return string(b)
+}
+
+// ParseMask parse the content inside {}
+func ParseMask(spec string) (Mask, error) {
+	// hashcat style: ?l?l?d, other characters are kept as it is
+	if strings.HasPrefix(spec, "?") {
+		var positions []string
+		for i := 0; i < len(spec); i++ {
+			if spec[i] == '?' && i+1 < len(spec) {
+				cs, ok := hashcatCharsets[spec[i+1]]
+				if !ok {
+					return nil, fmt.Errorf("unknown charset ?%c", spec[i+1])
+				}
+				positions = append(positions, cs)
+				i++
+				continue
+			}
+			positions = append(positions, string(spec[i]))
+		}
+		return CharsetMask{Positions: positions}, nil
+	}
+
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid mask {%s}", spec)
+	}
+
+	// numeric range: d:1-100
+	if parts[0] == "d" && strings.Contains(parts[1], "-") {
+		bounds := strings.SplitN(parts[1], "-", 2)
+		start, err := strconv.ParseInt(bounds[0], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		end, err := strconv.ParseInt(bounds[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if end < start {
+			return nil, fmt.Errorf("invalid range {%s}", spec)
+		}
+		width := 0
+		if len(bounds[0]) > 1 && bounds[0][0] == '0' {
+			width = len(bounds[0])
+		}
+		return RangeMask{Start: start, End: end, Width: width}, nil
+	}
+
+	// charset with length: a-z:3, hex:4
+	length, err := strconv.Atoi(parts[1])
+	if err != nil || length < 1 {
+		return nil, fmt.Errorf("invalid length in {%s}", spec)
+	}
+	cs, ok := charsets[parts[0]]
+	if !ok {
+		cs, err = expandCharset(parts[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+	positions := make([]string, length)
+	for i := range positions {
+		positions[i] = cs
+	}
+	return CharsetMask{Positions: positions}, nil
+}
+
+// expandCharset a-z0-9 -> abc...xyz012...9
+func expandCharset(raw string) (string, error) {
+	var b strings.Builder
+	seen := make(map[byte]bool)
+	for i := 0; i < len(raw); i++ {
+		from, to := raw[i], raw[i]
+		if i+2 < len(raw) && raw[i+1] == '-' {
+			to = raw[i+2]
+			i += 2
+		}
+		if to < from {
+			return "", fmt.Errorf("invalid charset %s", raw)
+		}
+		for c := int(from); c <= int(to); c++ {
+			if !seen[byte(c)] {
+				seen[byte(c)] = true
+				b.WriteByte(byte(c))
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// ExtractMasks replace masks with {{.mask0}} in template or {mask0} in plain string
+func ExtractMasks(s string, isTemplate bool) (string, []Mask, error) {
+	var masks []Mask
+	var parseErr error
+	out := maskRE.ReplaceAllStringFunc(s, func(m string) string {
+		mask, err := ParseMask(m[1 : len(m)-1])
+		if err != nil {
+			parseErr = err
+			return m
+		}
+		name := maskName(len(masks))
+		masks = append(masks, mask)
+		if isTemplate {
+			return "{{." + name + "}}"
+		}
+		return "{" + name + "}"
+	})
+	return out, masks, parseErr
+}
+
+func maskName(i int) string {
+	return fmt.Sprintf("mask%d", i)
+}
+
+// Keyspace is the number of values of all masks together
+func Keyspace(masks []Mask) int64 {
+	size := int64(1)
+	for _, m := range masks {
+		size = mulSaturate(size, m.Size())
+	}
+	return size
+}
+
+func mulSaturate(a, b int64) int64 {
+	if a != 0 && b > math.MaxInt64/a {
+		return math.MaxInt64
+	}
+	return a * b
+}
+
+// EnumerateMasks call fn with every combination of mask values, the last mask change fastest
+// it jump over the skipped values without generating them
+func EnumerateMasks(masks []Mask, fn func(values []string) bool) bool {
+	total := Keyspace(masks)
+	start := int64(0)
+	outMu.Lock()
+	if skipLeft > 0 {
+		if skipLeft >= total {
+			skipLeft -= total
+			outMu.Unlock()
+			return true
+		}
+		start = skipLeft
+		skipLeft = 0
+	}
+	outMu.Unlock()
+
+	values := make([]string, len(masks))
+	for idx := start; idx < total; idx++ {
+		i := idx
+		for j := len(masks) - 1; j >= 0; j-- {
+			size := masks[j].Size()
+			values[j] = masks[j].Value(i % size)
+			i /= size
+		}
+		if !fn(values) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnumerateMasksSkip(t *testing.T) {
+	// 2 masks with 2x3 values: a1 a2 a3 b1 b2 b3
+	masks := []Mask{CharsetMask{Positions: []string{"ab"}}, RangeMask{Start: 1, End: 3}}
+	all := []string{"a1", "a2", "a3", "b1", "b2", "b3"}
+
+	tests := []struct {
+		name     string
+		skip     int64
+		want     []string
+		wantLeft int64
+	}{
+		{"no skip", 0, all, 0},
+		{"skip inside", 2, all[2:], 0},
+		{"skip across the first mask", 4, all[4:], 0},
+		{"skip all", 6, nil, 0},
+		{"skip more than keyspace", 10, nil, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skipLeft = tt.skip
+			defer func() { skipLeft = 0 }()
+
+			var got []string
+			EnumerateMasks(masks, func(values []string) bool {
+				got = append(got, values[0]+values[1])
+				return true
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if skipLeft != tt.wantLeft {
+				t.Errorf("skipLeft = %d, want %d", skipLeft, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestParseMask(t *testing.T) {
+	tests := []struct {
+		spec  string
+		size  int64
+		first string
+		last  string
+	}{
+		{"d:1-100", 100, "1", "100"},
+		{"d:01-99", 99, "01", "99"},
+		{"a-z:2", 676, "aa", "zz"},
+		{"hex:2", 256, "00", "ff"},
+		{"?l?d", 260, "a0", "z9"},
+		{"?u-?d", 260, "A-0", "Z-9"},
+	}
+	for _, tt := range tests {
+		m, err := ParseMask(tt.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.spec, err)
+		}
+		if m.Size() != tt.size || m.Value(0) != tt.first || m.Value(m.Size()-1) != tt.last {
+			t.Errorf("%s: got size %d, %s..%s", tt.spec, m.Size(), m.Value(0), m.Value(m.Size()-1))
+		}
+	}
+}
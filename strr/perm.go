@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+var numberRE = regexp.MustCompile(`[0-9]+`)
+
+// Permutator gen subdomain permutations like altdns/gotator
+type Permutator struct {
+	Words []string
+	// Depth is how many times the permutations are applied again on the results
+	Depth int
+	// NumRange is how far to increase/decrease numbers, api2 -> api1, api3 with 1
+	NumRange int
+
+	labels []string
+}
+
+// host is a subdomain split into labels and the registered domain
+type host struct {
+	labels []string
+	root   string
+}
+
+func splitHost(raw string) (host, bool) {
+	raw = strings.Trim(strings.ToLower(strings.TrimSpace(raw)), ".")
+	root, err := publicsuffix.EffectiveTLDPlusOne(raw)
+	if err != nil {
+		return host{}, false
+	}
+	h := host{root: root}
+	sub := strings.TrimSuffix(strings.TrimSuffix(raw, root), ".")
+	if sub != "" {
+		h.labels = strings.Split(sub, ".")
+	}
+	return h, true
+}
+
+func (h host) String() string {
+	if len(h.labels) == 0 {
+		return h.root
+	}
+	return strings.Join(h.labels, ".") + "." + h.root
+}
+
+// Permutate return unique and sorted permutations, the known hosts are not included
+func (p *Permutator) Permutate(rawHosts []string) []string {
+	known := make(map[string]bool)
+	var frontier []host
+	labelSet := make(map[string]bool)
+	for _, raw := range rawHosts {
+		h, ok := splitHost(raw)
+		if !ok || known[h.String()] {
+			continue
+		}
+		known[h.String()] = true
+		frontier = append(frontier, h)
+		for _, l := range h.labels {
+			labelSet[l] = true
+		}
+	}
+	for l := range labelSet {
+		p.labels = append(p.labels, l)
+	}
+	sort.Strings(p.labels)
+
+	results := make(map[string]bool)
+	for depth := 0; depth < p.Depth && len(frontier) > 0; depth++ {
+		var next []host
+		for _, h := range frontier {
+			for _, alt := range p.alterations(h) {
+				name := alt.String()
+				if known[name] || results[name] {
+					continue
+				}
+				results[name] = true
+				next = append(next, alt)
+			}
+		}
+		frontier = next
+	}
+
+	var output []string
+	for name := range results {
+		output = append(output, name)
+	}
+	sort.Strings(output)
+	return output
+}
+
+// alterations gen every single change of the host
+func (p *Permutator) alterations(h host) []host {
+	var alts []host
+	add := func(labels []string) {
+		for _, l := range labels {
+			if !isValidLabel(l) {
+				return
+			}
+		}
+		alts = append(alts, host{labels: labels, root: h.root})
+	}
+
+	for _, word := range p.Words {
+		word = strings.ToLower(word)
+		// insert word at every label position
+		for i := 0; i <= len(h.labels); i++ {
+			labels := append(append(append([]string{}, h.labels[:i]...), word), h.labels[i:]...)
+			add(labels)
+		}
+		// prepend and append with '-' to every label
+		for i, l := range h.labels {
+			add(replaceLabel(h.labels, i, word+"-"+l))
+			add(replaceLabel(h.labels, i, l+"-"+word))
+			add(replaceLabel(h.labels, i, word+l))
+			add(replaceLabel(h.labels, i, l+word))
+		}
+	}
+
+	for i, l := range h.labels {
+		// increase and decrease numbers
+		for _, n := range numberAlterations(l, p.NumRange) {
+			add(replaceLabel(h.labels, i, n))
+		}
+		// swap label with labels of other known hosts
+		for _, other := range p.labels {
+			if other != l {
+				add(replaceLabel(h.labels, i, other))
+			}
+		}
+	}
+	return alts
+}
+
+func replaceLabel(labels []string, i int, value string) []string {
+	clone := append([]string{}, labels...)
+	clone[i] = value
+	return clone
+}
+
+// numberAlterations api2 -> api1, api3 and dev01 -> dev00, dev02
+func numberAlterations(label string, numRange int) []string {
+	var results []string
+	for _, loc := range numberRE.FindAllStringIndex(label, -1) {
+		raw := label[loc[0]:loc[1]]
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			continue
+		}
+		width := 0
+		if len(raw) > 1 && raw[0] == '0' {
+			width = len(raw)
+		}
+		for d := -numRange; d <= numRange; d++ {
+			if d == 0 || n+d < 0 {
+				continue
+			}
+			results = append(results, label[:loc[0]]+fmt.Sprintf("%0*d", width, n+d)+label[loc[1]:])
+		}
+	}
+	return results
+}
+
+func isValidLabel(l string) bool {
+	if l == "" || len(l) > 63 || strings.HasPrefix(l, "-") || strings.HasSuffix(l, "-") {
+		return false
+	}
+	for _, c := range l {
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	ModeCartesian = "cartesian"
+	ModeZip       = "zip"
+)
+
+var placeholderNameRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Wordlist is a wordlist bound to placeholder {Name}
+type Wordlist struct {
+	Name string
+	File string
+}
+
+// ParseWordlist parse wordlist in format: name=file
+func ParseWordlist(raw string) (Wordlist, error) {
+	parts := strings.SplitN(raw, "=", 2)
+	if len(parts) != 2 || !placeholderNameRE.MatchString(parts[0]) {
+		return Wordlist{}, fmt.Errorf("invalid wordlist %s, expected name=file", raw)
+	}
+	filename := parts[1]
+	if strings.HasPrefix(filename, "~") {
+		filename, _ = homedir.Expand(filename)
+	}
+	if !FileExists(filename) {
+		return Wordlist{}, fmt.Errorf("wordlist %s not found", filename)
+	}
+	return Wordlist{Name: parts[0], File: filename}, nil
+}
+
+// StreamLines read file line by line without loading all of it, stop when fn return false
+func StreamLines(filename string, fn func(line string) bool) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		val := strings.TrimSpace(scanner.Text())
+		if val == "" {
+			continue
+		}
+		if !fn(val) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// Cartesian call fn with every combination of the wordlists
+// each wordlist is streamed from disk so only one line of each is in memory
+func Cartesian(wordlists []Wordlist, fn func(values map[string]string) bool) error {
+	values := make(map[string]string)
+	stop := false
+	var walk func(i int) error
+	walk = func(i int) error {
+		if i == len(wordlists) {
+			if !fn(values) {
+				stop = true
+			}
+			return nil
+		}
+		var walkErr error
+		err := StreamLines(wordlists[i].File, func(line string) bool {
+			values[wordlists[i].Name] = line
+			if walkErr = walk(i + 1); walkErr != nil {
+				return false
+			}
+			return !stop
+		})
+		if err != nil {
+			return err
+		}
+		return walkErr
+	}
+	return walk(0)
+}
+
+// Zip call fn with the n-th line of every wordlist, stop at the end of the shortest one
+func Zip(wordlists []Wordlist, fn func(values map[string]string) bool) error {
+	var scanners []*bufio.Scanner
+	for _, w := range wordlists {
+		file, err := os.Open(w.File)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		scanners = append(scanners, bufio.NewScanner(file))
+	}
+
+	values := make(map[string]string)
+	for {
+		for i, sc := range scanners {
+			line, ok := nextLine(sc)
+			if !ok {
+				return sc.Err()
+			}
+			values[wordlists[i].Name] = line
+		}
+		if !fn(values) {
+			return nil
+		}
+	}
+}
+
+// nextLine return the next non empty line
+func nextLine(sc *bufio.Scanner) (string, bool) {
+	for sc.Scan() {
+		val := strings.TrimSpace(sc.Text())
+		if val != "" {
+			return val, true
+		}
+	}
+	return "", false
+}
+
+// Combine stream the combinations base on mode, fn is called once when there is no wordlist
+func Combine(mode string, wordlists []Wordlist, fn func(values map[string]string) bool) error {
+	if len(wordlists) == 0 {
+		fn(nil)
+		return nil
+	}
+	if mode == ModeZip {
+		return Zip(wordlists, fn)
+	}
+	return Cartesian(wordlists, fn)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// helpers available in template
+var funcMap = template.FuncMap{
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"replace": func(s, old, new string) string { return strings.ReplaceAll(s, old, new) },
+	"split":   strings.Split,
+	"trim":    strings.TrimSpace,
+}
+
+// ParseTemplate parse -t template, the replace string like {} become {{.Word}}
+// and the placeholder of wordlist like {env} become {{.env}}
+func ParseTemplate(format string) (*template.Template, error) {
+	format = strings.ReplaceAll(format, replaceString, "{{.Word}}")
+	for _, w := range wordlists {
+		format = strings.ReplaceAll(format, "{"+w.Name+"}", "{{."+w.Name+"}}")
+	}
+	return template.New("").Funcs(funcMap).Option("missingkey=zero").Parse(format)
+}
+
+// RenderTemplate render template with data
+func RenderTemplate(t *template.Template, data map[string]string) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := t.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ParseURL parse input into template variables, same as ghd
+func ParseURL(raw string) map[string]string {
+	target := make(map[string]string)
+	if raw == "" {
+		return target
+	}
+	target["Raw"] = raw
+	u, err := url.Parse(raw)
+
+	// something wrong so parsing it again
+	if err != nil || u.Scheme == "" || strings.Contains(u.Scheme, ".") {
+		raw = fmt.Sprintf("https://%v", raw)
+		u, err = url.Parse(raw)
+		if err != nil {
+			return target
+		}
+	}
+	var hostname string
+	port := u.Port()
+	domain := u.Hostname()
+
+	if u.Port() == "" {
+		if strings.Contains(u.Scheme, "https") {
+			port = "443"
+		} else {
+			port = "80"
+		}
+		hostname = u.Hostname()
+	} else {
+		// ignore common port in Host
+		if u.Port() == "443" || u.Port() == "80" {
+			hostname = u.Hostname()
+		} else {
+			hostname = u.Hostname() + ":" + u.Port()
+		}
+	}
+
+	target["Scheme"] = u.Scheme
+	target["Path"] = u.Path
+	target["Domain"] = domain
+
+	target["Org"] = domain
+	suffix, ok := publicsuffix.PublicSuffix(domain)
+	if ok {
+		target["Org"] = strings.Replace(domain, fmt.Sprintf(".%s", suffix), "", -1)
+	} else {
+		if strings.Contains(domain, ".") {
+			parts := strings.Split(domain, ".")
+			if len(parts) == 2 {
+				target["Org"] = parts[0]
+			} else {
+				target["Org"] = parts[len(parts)-2]
+			}
+		}
+	}
+
+	target["Host"] = hostname
+	target["Port"] = port
+	target["RawQuery"] = u.RawQuery
+	target["BaseURL"] = fmt.Sprintf("%v://%v", u.Scheme, u.Host)
+	target["Extension"] = filepath.Ext(u.Path)
+	return target
+}
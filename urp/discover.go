@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/url"
+	"os"
+)
+
+// Discoverer append candidate params with unique canary values to URLs
+type Discoverer struct {
+	Params []string
+	MaxLen int
+	prefix string
+	count  int
+	// mapping is written as: canary<TAB>param<TAB>url
+	mapping *os.File
+}
+
+// NewDiscoverer create discoverer, mappingFile can be empty
+func NewDiscoverer(params []string, maxLen int, mappingFile string) (*Discoverer, error) {
+	d := &Discoverer{
+		Params: params,
+		MaxLen: maxLen,
+		prefix: randomLetters(5),
+	}
+	if mappingFile != "" {
+		f, err := os.Create(mappingFile)
+		if err != nil {
+			return nil, err
+		}
+		d.mapping = f
+	}
+	return d, nil
+}
+
+// Close close the mapping file
+func (d *Discoverer) Close() {
+	if d.mapping != nil {
+		d.mapping.Close()
+	}
+}
+
+// nextCanary gen unique canary, fixed width so one canary is never part of another
+func (d *Discoverer) nextCanary() string {
+	d.count++
+	return fmt.Sprintf("%s%06x", d.prefix, d.count)
+}
+
+// Generate split params into URLs that not longer than MaxLen
+func (d *Discoverer) Generate(raw string) []string {
+	var urlList []string
+	existing := make(map[string]bool)
+	if u, err := url.Parse(raw); err == nil {
+		for k := range u.Query() {
+			existing[k] = true
+		}
+	}
+
+	r := SplitRawURL(raw)
+	base := r.Query
+	current := base
+	var batch [][2]string
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		clone := r
+		clone.Query = current
+		clone.HasQuery = true
+		generated := clone.String()
+		urlList = append(urlList, generated)
+		if d.mapping != nil {
+			for _, item := range batch {
+				fmt.Fprintf(d.mapping, "%s\t%s\t%s\n", item[1], item[0], generated)
+			}
+		}
+		current = base
+		batch = nil
+	}
+
+	for _, param := range d.Params {
+		if existing[param] {
+			continue
+		}
+		canary := d.nextCanary()
+		pair := url.QueryEscape(param) + "=" + canary
+
+		next := pair
+		if current != "" {
+			next = current + "&" + pair
+		}
+		// the URL is full, start a new one
+		if len(batch) > 0 && len(r.Prefix)+len(r.Path)+len(next)+len(r.Fragment)+1 > d.MaxLen {
+			flush()
+			next = pair
+			if current != "" {
+				next = current + "&" + pair
+			}
+		}
+		current = next
+		batch = append(batch, [2]string{param, canary})
+	}
+	flush()
+	return urlList
+}
+
+func randomLetters(n int) string {
+	letters := "abcdefghijklmnopqrstuvwxyz"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letters[rand.Intn(len(letters))]
+	}
+	return string(b)
+}
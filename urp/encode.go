@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+)
+
+// injectMarker is injected instead of the payload when encoders are used
+// so the encoded payload won't be decoded again by url.QueryUnescape/PathUnescape
+const injectMarker = "URPINJECTMARKER"
+
+// Encoders available for -enc
+var Encoders = map[string]func(string) string{
+	"none":           func(s string) string { return s },
+	"url":            URLEncodeSpecial,
+	"url-all":        URLEncodeAll,
+	"double-url":     func(s string) string { return URLEncodeSpecial(URLEncodeSpecial(s)) },
+	"base64":         func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"unicode-escape": UnicodeEscape,
+	"html-entity":    HTMLEntity,
+	"case-randomize": CaseRandomize,
+	"utf8-overlong":  UTF8Overlong,
+}
+
+// ParseEncodeChains parse chains in format: url,double-url,base64+url
+// ',' separate chains and '+' join encoders in a chain
+func ParseEncodeChains(raw string) ([][]string, error) {
+	var chains [][]string
+	for _, rawChain := range strings.Split(raw, ",") {
+		rawChain = strings.TrimSpace(rawChain)
+		if rawChain == "" {
+			continue
+		}
+		var chain []string
+		for _, name := range strings.Split(rawChain, "+") {
+			name = strings.TrimSpace(name)
+			if _, ok := Encoders[name]; !ok {
+				return nil, fmt.Errorf("unknown encoder: %s", name)
+			}
+			chain = append(chain, name)
+		}
+		chains = append(chains, chain)
+	}
+	return chains, nil
+}
+
+// EncodePayload run payload through every encoder in the chain
+func EncodePayload(payload string, chain []string) string {
+	for _, name := range chain {
+		payload = Encoders[name](payload)
+	}
+	return payload
+}
+
+// PayloadVariants encode payload with every chain, return the payload itself if no chain
+func PayloadVariants(payload string) []string {
+	if len(encodeChains) == 0 {
+		return []string{payload}
+	}
+	var variants []string
+	for _, chain := range encodeChains {
+		variants = append(variants, EncodePayload(payload, chain))
+	}
+	return variants
+}
+
+func isUnreserved(c rune) bool {
+	return c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune("-_.~", c))
+}
+
+// URLEncodeSpecial percent-encode everything except unreserved characters
+func URLEncodeSpecial(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if isUnreserved(rune(c)) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// URLEncodeAll percent-encode every byte
+func URLEncodeAll(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// UnicodeEscape encode special characters as \uXXXX
+func UnicodeEscape(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if isUnreserved(c) {
+			b.WriteRune(c)
+			continue
+		}
+		fmt.Fprintf(&b, "\\u%04x", c)
+	}
+	return b.String()
+}
+
+// HTMLEntity encode special characters as &#xXX;
+func HTMLEntity(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if isUnreserved(c) {
+			b.WriteRune(c)
+			continue
+		}
+		fmt.Fprintf(&b, "&#x%x;", c)
+	}
+	return b.String()
+}
+
+// CaseRandomize randomly flip case of letters
+func CaseRandomize(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if rand.Intn(2) == 0 {
+			b.WriteRune(unicode.ToUpper(c))
+		} else {
+			b.WriteRune(unicode.ToLower(c))
+		}
+	}
+	return b.String()
+}
+
+// UTF8Overlong encode special ASCII characters as 2 bytes overlong UTF-8 sequence
+// e.g: '/' -> %c0%af
+func UTF8Overlong(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if c >= 0x80 || isUnreserved(rune(c)) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02x%%%02x", 0xC0|(c>>6), 0x80|(c&0x3F))
+	}
+	return b.String()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RawURL is a URL split byte by byte without decoding anything
+type RawURL struct {
+	// Prefix is scheme and authority, e.g: https://target.com:8443
+	Prefix   string
+	Path     string
+	Query    string
+	HasQuery bool
+	// Fragment include the '#'
+	Fragment string
+}
+
+// SplitRawURL split URL into parts and keep the original encoding
+func SplitRawURL(raw string) RawURL {
+	var r RawURL
+	if i := strings.Index(raw, "#"); i != -1 {
+		r.Fragment = raw[i:]
+		raw = raw[:i]
+	}
+	if i := strings.Index(raw, "?"); i != -1 {
+		r.Query = raw[i+1:]
+		r.HasQuery = true
+		raw = raw[:i]
+	}
+
+	start := 0
+	if i := strings.Index(raw, "://"); i != -1 {
+		start = i + 3
+	}
+	if i := strings.Index(raw[start:], "/"); i != -1 {
+		r.Prefix = raw[:start+i]
+		r.Path = raw[start+i:]
+	} else {
+		r.Prefix = raw
+	}
+	return r
+}
+
+// String join the parts back
+func (r RawURL) String() string {
+	s := r.Prefix + r.Path
+	if r.HasQuery {
+		s += "?" + r.Query
+	}
+	return s + r.Fragment
+}
+
+// selectIndexes return which token to replace base on -i, each item is one output
+func selectIndexes(total int) [][]int {
+	var selected [][]int
+	switch place {
+	case ReplaceAll:
+		var all []int
+		for i := 0; i < total; i++ {
+			all = append(all, i)
+		}
+		selected = append(selected, all)
+	case ReplaceOneByOne:
+		for i := 0; i < total; i++ {
+			selected = append(selected, []int{i})
+		}
+	default:
+		var toReplacePlace int
+		if strings.HasPrefix(place, "-") {
+			p, err := strconv.Atoi(place[1:])
+			if err != nil {
+				p = 0
+			}
+			toReplacePlace = total - p
+		} else {
+			p, err := strconv.Atoi(place)
+			if err != nil {
+				p = 0
+			}
+			toReplacePlace = p
+		}
+		if toReplacePlace >= total {
+			toReplacePlace = total - 1
+		}
+		if toReplacePlace < 0 {
+			toReplacePlace = 0
+		}
+		selected = append(selected, []int{toReplacePlace})
+	}
+	return selected
+}
+
+// ExactQueryBuilder only replace the value of targeted param
+// param order, duplicate params, empty values and encoding are kept as it is
+func ExactQueryBuilder(urlString string, payload string) ([]Injection, error) {
+	urlList := make([]Injection, 0)
+	r := SplitRawURL(urlString)
+	if r.Query == "" {
+		return urlList, fmt.Errorf("no query")
+	}
+
+	pairs := strings.Split(r.Query, "&")
+	for _, indexes := range selectIndexes(len(pairs)) {
+		clone := append(pairs[:0:0], pairs...)
+		for _, i := range indexes {
+			kv := strings.SplitN(clone[i], "=", 2)
+			value := ""
+			if len(kv) == 2 {
+				value = kv[1]
+			}
+			clone[i] = kv[0] + "=" + injectValue(value, payload)
+		}
+		r.Query = strings.Join(clone, "&")
+		urlList = append(urlList, newExactInjection(PositionQuery, pairs, indexes, r.String()))
+	}
+	return urlList, nil
+}
+
+// ExactPathBuilder only replace the targeted path segment
+func ExactPathBuilder(urlString string, payload string) ([]Injection, error) {
+	urlList := make([]Injection, 0)
+	r := SplitRawURL(urlString)
+	if RemoveQuery {
+		r.Query = ""
+		r.HasQuery = false
+	}
+
+	paths := strings.Split(strings.TrimPrefix(r.Path, "/"), "/")
+	for _, indexes := range selectIndexes(len(paths)) {
+		pathClone := append(paths[:0:0], paths...)
+		for _, i := range indexes {
+			pathClone[i] = injectValue(pathClone[i], payload)
+		}
+		// remove last paths after the payload
+		if place == ReplaceOneByOne && !appendMode && removeLastPath {
+			pathClone = pathClone[:indexes[0]+1]
+		}
+		clone := r
+		clone.Path = "/" + strings.Join(pathClone, "/")
+		urlList = append(urlList, newExactInjection(PositionPath, paths, indexes, clone.String()))
+	}
+
+	if last {
+		clone := r
+		clone.Path = r.Path + payload
+		urlList = append(urlList, Injection{Position: PositionPath, Index: len(paths), Result: clone.String()})
+
+		clone.Path = r.Path + "?" + payload
+		urlList = append(urlList, Injection{Position: PositionPath, Index: len(paths), Result: clone.String()})
+	}
+	return urlList, nil
+}
+
+// newExactInjection record which token was replaced, index is -1 when replace all
+func newExactInjection(position string, tokens []string, indexes []int, result string) Injection {
+	if len(indexes) != 1 {
+		return Injection{Position: position, Index: -1, Result: result}
+	}
+	i := indexes[0]
+	name := tokens[i]
+	if position == PositionQuery {
+		name = strings.SplitN(name, "=", 2)[0]
+	}
+	return Injection{Position: position, Name: name, Index: i, Result: result}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSplitRawURL(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want RawURL
+	}{
+		{"simple", "https://a.com/p?id=1", RawURL{Prefix: "https://a.com", Path: "/p", Query: "id=1", HasQuery: true}},
+		{"encoded", "https://a.com/a%2fb?q=%27%20x&r=a%26b", RawURL{Prefix: "https://a.com", Path: "/a%2fb", Query: "q=%27%20x&r=a%26b", HasQuery: true}},
+		{"duplicate", "https://a.com/?id=1&id=2&id=1", RawURL{Prefix: "https://a.com", Path: "/", Query: "id=1&id=2&id=1", HasQuery: true}},
+		{"empty values", "https://a.com/p?a=&b&=c&&", RawURL{Prefix: "https://a.com", Path: "/p", Query: "a=&b&=c&&", HasQuery: true}},
+		{"empty query", "https://a.com/p?", RawURL{Prefix: "https://a.com", Path: "/p", HasQuery: true}},
+		{"no path", "https://a.com:8443?x=1", RawURL{Prefix: "https://a.com:8443", Query: "x=1", HasQuery: true}},
+		{"fragment", "https://a.com/p?x=1#/route?y=2", RawURL{Prefix: "https://a.com", Path: "/p", Query: "x=1", HasQuery: true, Fragment: "#/route?y=2"}},
+		{"no scheme", "a.com/p/q", RawURL{Prefix: "a.com", Path: "/p/q"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitRawURL(tt.raw)
+			if got != tt.want {
+				t.Errorf("SplitRawURL(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+			if got.String() != tt.raw {
+				t.Errorf("String() = %q, want %q", got.String(), tt.raw)
+			}
+		})
+	}
+}
+
+func TestExactQueryBuilder(t *testing.T) {
+	place, appendMode = ReplaceOneByOne, false
+	tests := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{"duplicate", "https://a.com/?id=1&id=2", []string{"https://a.com/?id=X&id=2", "https://a.com/?id=1&id=X"}},
+		{"encoded kept", "https://a.com/?a=%27&b=c%26d", []string{"https://a.com/?a=X&b=c%26d", "https://a.com/?a=%27&b=X"}},
+		{"empty values", "https://a.com/?a=&b", []string{"https://a.com/?a=X&b", "https://a.com/?a=&b=X"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExactQueryBuilder(tt.raw, "X")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d results, want %d", len(got), len(tt.want))
+			}
+			for i, injection := range got {
+				if injection.Result != tt.want[i] {
+					t.Errorf("result %d = %q, want %q", i, injection.Result, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/base64"
 	"flag"
 	"fmt"
 	"net/url"
@@ -42,7 +43,24 @@ var (
 	RemoveQuery     bool
 	TrimLastSlash   bool
 	RemoveDummyPort bool
+	encodeChain     string
+	rawInput        bool
+	rawRequestFile  string
+	rawPositions    string
+	outputDir       string
+	exactMode       bool
+	paramWordlist   string
+	paramMapping    string
+	maxURLLength    int
+	positionList    string
+	showTag         bool
+	outputFormat    string
+	rulesFile       string
+	bypassMode      bool
+	ruleSet         *RuleSet
+	extraPositions  []string
 	payloadList     []string
+	encodeChains    [][]string
 )
 
 func main() {
@@ -64,8 +82,33 @@ func main() {
 	flag.BoolVar(&InjectAll, "A", true, "Inject All")
 	flag.StringVar(&injectWords, "I", "FUZZ", "Inject Words to replace")
 	flag.StringVar(&toInjectList, "iL", "", "Payload list")
+	flag.StringVar(&encodeChain, "enc", "", "Encode payload, ',' separate variants and '+' chain encoders (Ex: none,url,double-url,base64+url)\n  available: none, url, url-all, double-url, base64, unicode-escape, html-entity, case-randomize, utf8-overlong")
+	flag.BoolVar(&exactMode, "x", false, "Exact mode, only replace the targeted token and keep param order, duplicate params and the original encoding")
+	flag.StringVar(&positionList, "pos", "", "Positions to inject, replace the default query and path (Ex: query,path,dup)\n  query, path, dup (a=1&a=FUZZ), array (a[]=FUZZ), filename (/file.FUZZ.php), matrix (;a=FUZZ), fragment, userinfo or all")
+	flag.BoolVar(&showTag, "tag", false, "Prefix the output with the position, e.g: [dup]")
+	flag.BoolVar(&bypassMode, "bypass", false, "Generate path normalization bypass variants (e.g: /./admin, /admin..;/), header variants need -of raw/nuclei/json")
+	flag.StringVar(&rulesFile, "rules", "", "YAML rules file to pick payloads per param (by param name, value or position)")
+	flag.StringVar(&outputFormat, "of", OutputPlain, "Output format\n  plain: URL line by line\n  json: JSONL with the injection point\n  ffuf: FUZZ-marked templates\n  nuclei: nuclei JSONL input (nuclei -im jsonl)\n  raw: raw GET requests in base64 line by line (or files with -od)")
+	// param discovery
+	flag.StringVar(&paramWordlist, "pw", "", "Param wordlist, append candidate params with unique canary values to each URL")
+	flag.StringVar(&paramMapping, "pm", "", "File to write which canary belong to which param (canary, param, url)")
+	flag.IntVar(&maxURLLength, "ml", 2000, "Max URL length when append candidate params")
+	// raw request
+	flag.BoolVar(&rawInput, "r", false, "Input is raw request in base64 line by line (bparse -f output)")
+	flag.StringVar(&rawRequestFile, "R", "", "Raw request file (Burp saved request)")
+	flag.StringVar(&rawPositions, "rp", "query,body,json,multipart,header,cookie", "Where to inject in raw request")
+	flag.StringVar(&outputDir, "od", "", "Write each generated raw request to a file in this folder (default print base64 line by line)")
 	flag.Parse()
 
+	if encodeChain != "" {
+		chains, err := ParseEncodeChains(encodeChain)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to parse encoders: %s\n", err)
+			os.Exit(-1)
+		}
+		encodeChains = chains
+	}
+
 	// prepare words
 	payloadList = append(payloadList, injectWords)
 	if toInjectList != "" {
@@ -84,6 +127,48 @@ func main() {
 		}
 	}
 
+	switch outputFormat {
+	case OutputPlain, OutputJSON, OutputNuclei, OutputRaw:
+	case OutputFFUF:
+		// ffuf do the payloads and encoding
+		payloadList = []string{"FUZZ"}
+		encodeChains = nil
+		rulesFile = ""
+	default:
+		fmt.Fprintf(os.Stderr, "invalid output format: %s\n", outputFormat)
+		os.Exit(-1)
+	}
+
+	if rulesFile != "" {
+		rs, err := LoadRules(rulesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load rules: %s\n", err)
+			os.Exit(-1)
+		}
+		ruleSet = rs
+	}
+
+	if positionList != "" {
+		positions, err := ParsePositions(positionList)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to parse positions: %s\n", err)
+			os.Exit(-1)
+		}
+		extraPositions = positions
+	}
+
+	if outputDir != "" {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create output folder %s [%s]\n", outputDir, err)
+			os.Exit(-1)
+		}
+	}
+
+	if rawInput || rawRequestFile != "" {
+		RunRawRequests()
+		return
+	}
+
 	if blacklistExt != "" {
 		bl := strings.Split(blacklistExt, ",")
 		for _, e := range bl {
@@ -96,6 +181,27 @@ func main() {
 	}
 	sort.Strings(IgnoreExtensions)
 
+	var discoverer *Discoverer
+	if paramWordlist != "" {
+		params := ReadingLines(paramWordlist)
+		if len(params) == 0 {
+			fmt.Fprintf(os.Stderr, "no param found in %s\n", paramWordlist)
+			os.Exit(-1)
+		}
+		d, err := NewDiscoverer(params, maxURLLength, paramMapping)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create mapping file %s [%s]\n", paramMapping, err)
+			os.Exit(-1)
+		}
+		defer d.Close()
+		discoverer = d
+	}
+
+	queryBuilder, pathBuilder := QueryBuilder, PathBuilder
+	if exactMode {
+		queryBuilder, pathBuilder = ExactQueryBuilder, ExactPathBuilder
+	}
+
 	sc := bufio.NewScanner(os.Stdin)
 	for sc.Scan() {
 		raw := strings.TrimSpace(sc.Text())
@@ -117,20 +223,63 @@ func main() {
 			}
 		}
 
+		if discoverer != nil {
+			for _, gU := range discoverer.Generate(raw) {
+				fmt.Println(gU)
+			}
+			continue
+		}
+
+		if bypassMode {
+			for _, injection := range BypassBuilder(raw) {
+				PrintInjection(raw, injection, "")
+			}
+			continue
+		}
+
+		target := u.String()
+		if exactMode {
+			target = raw
+		}
+
+		// payloads are picked for each injection point later
+		loopPayloads := payloadList
+		if ruleSet != nil {
+			loopPayloads = []string{""}
+		}
+
 		// really start to do something
-		for _, payload := range payloadList {
-			var finalUrls []string
+		for _, originPayload := range loopPayloads {
+			var finalUrls []Injection
+			payload := originPayload
+			// encoded payloads are put in after the URLs are built
+			if len(encodeChains) > 0 || ruleSet != nil {
+				payload = injectMarker
+			}
 
 			switch {
+			case len(extraPositions) > 0:
+				for _, position := range extraPositions {
+					switch position {
+					case PositionQuery:
+						urls, _ := queryBuilder(target, payload)
+						finalUrls = append(finalUrls, urls...)
+					case PositionPath:
+						urls, _ := pathBuilder(target, payload)
+						finalUrls = append(finalUrls, urls...)
+					default:
+						finalUrls = append(finalUrls, ExtraBuilder(raw, payload, position)...)
+					}
+				}
 			case query:
-				urls, err := QueryBuilder(u.String(), payload)
+				urls, err := queryBuilder(target, payload)
 				finalUrls = append(finalUrls, urls...)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "[QUERY] Failed to generate %s with the payload %s\n", u.String(), payload)
 					continue
 				}
 			case path:
-				urls, err := PathBuilder(u.String(), payload)
+				urls, err := pathBuilder(target, payload)
 				finalUrls = append(finalUrls, urls...)
 
 				if err != nil {
@@ -140,7 +289,7 @@ func main() {
 			default:
 				// query
 				if !RemoveQuery {
-					urls, err := QueryBuilder(u.String(), payload)
+					urls, err := queryBuilder(target, payload)
 					finalUrls = append(finalUrls, urls...)
 					if err != nil {
 						fmt.Fprintf(os.Stderr, "[QUERY] Failed to generate %s with the payload %s\n", u.String(), payload)
@@ -149,7 +298,7 @@ func main() {
 				}
 
 				// path
-				urls, err := PathBuilder(u.String(), payload)
+				urls, err := pathBuilder(target, payload)
 				finalUrls = append(finalUrls, urls...)
 
 				if err != nil {
@@ -158,20 +307,35 @@ func main() {
 				}
 			}
 
-			for _, gU := range finalUrls {
+			for _, injection := range finalUrls {
 				if TrimLastSlash {
-					gU = strings.TrimRight(gU, "/")
+					injection.Result = strings.TrimRight(injection.Result, "/")
+				}
+				if payload != injectMarker {
+					PrintInjection(raw, injection, originPayload)
+					continue
+				}
+
+				payloads := []string{originPayload}
+				if ruleSet != nil {
+					payloads = ruleSet.Select(injection.Position, injection.Name, injectionValue(u, injection))
+				}
+				gU := injection.Result
+				for _, p := range payloads {
+					for _, encoded := range PayloadVariants(p) {
+						injection.Result = strings.ReplaceAll(gU, injectMarker, encoded)
+						PrintInjection(raw, injection, encoded)
+					}
 				}
-				fmt.Println(gU)
 			}
 		}
 	}
 
 }
 
-func QueryBuilder(urlString string, payload string) ([]string, error) {
+func QueryBuilder(urlString string, payload string) ([]Injection, error) {
 	pp := make([]string, 0)
-	urlList := make([]string, 0)
+	urlList := make([]Injection, 0)
 
 	u, err := url.Parse(urlString)
 	if err != nil {
@@ -199,13 +363,13 @@ func QueryBuilder(urlString string, payload string) ([]string, error) {
 		}
 		u.RawQuery = qs.Encode()
 		uRawQuery, _ := url.QueryUnescape(u.String())
-		urlList = append(urlList, uRawQuery)
+		urlList = append(urlList, Injection{Position: PositionQuery, Index: -1, Result: uRawQuery})
 	case ReplaceOneByOne:
 		for i := 0; i < len(pp); i++ {
 			cloneURL := &url.URL{}
 			err := copier.Copy(cloneURL, u)
 			if err != nil {
-				return []string{}, fmt.Errorf("Failed to clone url")
+				return []Injection{}, fmt.Errorf("Failed to clone url")
 			}
 			qs := cloneURL.Query()
 			if appendMode {
@@ -215,7 +379,7 @@ func QueryBuilder(urlString string, payload string) ([]string, error) {
 			}
 			cloneURL.RawQuery = qs.Encode()
 			cloneURLRawQuery, _ := url.QueryUnescape(cloneURL.String())
-			urlList = append(urlList, cloneURLRawQuery)
+			urlList = append(urlList, Injection{Position: PositionQuery, Name: pp[i], Index: i, Result: cloneURLRawQuery})
 		}
 	default:
 		var toReplacePlace int
@@ -247,13 +411,13 @@ func QueryBuilder(urlString string, payload string) ([]string, error) {
 		}
 		u.RawQuery = qs.Encode()
 		uRawQuery, _ := url.QueryUnescape(u.String())
-		urlList = append(urlList, uRawQuery)
+		urlList = append(urlList, Injection{Position: PositionQuery, Name: pp[toReplacePlace], Index: toReplacePlace, Result: uRawQuery})
 	}
 	return urlList, nil
 }
 
-func PathBuilder(urlString string, payload string) ([]string, error) {
-	urlList := make([]string, 0)
+func PathBuilder(urlString string, payload string) ([]Injection, error) {
+	urlList := make([]Injection, 0)
 
 	u, err := url.Parse(urlString)
 	if err != nil {
@@ -286,14 +450,14 @@ func PathBuilder(urlString string, payload string) ([]string, error) {
 		}
 		u.Path = strings.Join(paths, "/")
 		uRawPath, _ := url.PathUnescape(u.String())
-		urlList = append(urlList, uRawPath)
+		urlList = append(urlList, Injection{Position: PositionPath, Index: -1, Result: uRawPath})
 	case ReplaceOneByOne:
 		for i := 0; i < len(paths); i++ {
 
 			cloneURL := &url.URL{}
 			err := copier.Copy(cloneURL, u)
 			if err != nil {
-				return []string{}, fmt.Errorf("Failed to clone url")
+				return []Injection{}, fmt.Errorf("Failed to clone url")
 			}
 			pathClone := append(paths[:0:0], paths...)
 			if appendMode {
@@ -308,7 +472,7 @@ func PathBuilder(urlString string, payload string) ([]string, error) {
 
 			cloneURL.Path = strings.Join(pathClone, "/")
 			cloneURLRawPath, _ := url.PathUnescape(cloneURL.String())
-			urlList = append(urlList, cloneURLRawPath)
+			urlList = append(urlList, Injection{Position: PositionPath, Name: paths[i], Index: i, Result: cloneURLRawPath})
 		}
 	default:
 		var toReplacePlace int
@@ -331,6 +495,7 @@ func PathBuilder(urlString string, payload string) ([]string, error) {
 			toReplacePlace = len(paths) - 1
 		}
 
+		name := paths[toReplacePlace]
 		if appendMode {
 			paths[toReplacePlace] = paths[toReplacePlace] + payload
 		} else {
@@ -338,23 +503,23 @@ func PathBuilder(urlString string, payload string) ([]string, error) {
 		}
 		u.Path = strings.Join(paths, "/")
 		uRawPath, _ := url.PathUnescape(u.String())
-		urlList = append(urlList, uRawPath)
+		urlList = append(urlList, Injection{Position: PositionPath, Name: name, Index: toReplacePlace, Result: uRawPath})
 	}
 
 	if last {
 		cloneURL := &url.URL{}
 		err := copier.Copy(cloneURL, u)
 		if err != nil {
-			return []string{}, fmt.Errorf("Failed to clone url")
+			return []Injection{}, fmt.Errorf("Failed to clone url")
 		}
 		pathClone := append(paths[:0:0], paths...)
 		cloneURL.Path = strings.Join(pathClone, "/") + payload
 		cloneURLRawPath, _ := url.PathUnescape(cloneURL.String())
-		urlList = append(urlList, cloneURLRawPath)
+		urlList = append(urlList, Injection{Position: PositionPath, Index: len(pathClone), Result: cloneURLRawPath})
 
 		cloneURL.Path = strings.Join(pathClone, "/") + "?" + payload
 		cloneURLRawPath, _ = url.PathUnescape(cloneURL.String())
-		urlList = append(urlList, cloneURLRawPath)
+		urlList = append(urlList, Injection{Position: PositionPath, Index: len(pathClone), Result: cloneURLRawPath})
 	}
 
 	return urlList, nil
@@ -371,3 +536,81 @@ func BlacklistExt(u *url.URL) bool {
 		return false
 	}
 }
+
+// injectionValue get the original value of the injection point
+func injectionValue(u *url.URL, injection Injection) string {
+	switch injection.Position {
+	case PositionPath, PositionFilename:
+		return injection.Name
+	default:
+		return u.Query().Get(injection.Name)
+	}
+}
+
+// ReadingLines Reading file and return content as []string
+func ReadingLines(filename string) []string {
+	var result []string
+	file, err := os.Open(filename)
+	if err != nil {
+		return result
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		val := strings.TrimSpace(scanner.Text())
+		if val == "" {
+			continue
+		}
+		result = append(result, val)
+	}
+	return result
+}
+
+// RunRawRequests inject payloads to raw requests from -R file or base64 lines from stdin
+func RunRawRequests() {
+	var rawRequests []string
+	if rawRequestFile != "" {
+		content, err := os.ReadFile(rawRequestFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read raw request %s [%s]\n", rawRequestFile, err)
+			return
+		}
+		rawRequests = append(rawRequests, string(content))
+	}
+	if rawInput {
+		sc := bufio.NewScanner(os.Stdin)
+		sc.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" {
+				continue
+			}
+			decoded, err := base64.StdEncoding.DecodeString(line)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "failed to decode base64 request [%s]\n", err)
+				continue
+			}
+			rawRequests = append(rawRequests, string(decoded))
+		}
+	}
+
+	positions := make(map[string]bool)
+	for _, p := range strings.Split(rawPositions, ",") {
+		positions[strings.TrimSpace(p)] = true
+	}
+	for _, raw := range rawRequests {
+		req, err := ParseRawRequest(raw)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to parse raw request [%s]\n", err)
+			continue
+		}
+		for _, originPayload := range payloadList {
+			for _, payload := range PayloadVariants(originPayload) {
+				for _, injection := range RequestBuilder(req, payload, positions) {
+					PrintRawRequest(injection.Result)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Output formats
+const (
+	OutputPlain  = "plain"
+	OutputJSON   = "json"
+	OutputFFUF   = "ffuf"
+	OutputNuclei = "nuclei"
+	OutputRaw    = "raw"
+)
+
+// OutputRecord is one line of JSON output
+type OutputRecord struct {
+	Original string   `json:"original"`
+	URL      string   `json:"url"`
+	Position string   `json:"position"`
+	Name     string   `json:"name,omitempty"`
+	Index    int      `json:"index"`
+	Payload  string   `json:"payload"`
+	Headers  []string `json:"headers,omitempty"`
+}
+
+// NucleiRecord is the JSONL input of nuclei (nuclei -im jsonl)
+type NucleiRecord struct {
+	Request struct {
+		Method   string `json:"method"`
+		Endpoint string `json:"endpoint"`
+		Raw      string `json:"raw"`
+	} `json:"request"`
+}
+
+// ffuf templates repeat a lot so only print the new one
+var seenTemplates = make(map[string]bool)
+
+// PrintInjection print the generated URL base on output format
+func PrintInjection(original string, injection Injection, payload string) {
+	switch outputFormat {
+	case OutputJSON:
+		fmt.Println(toJSON(OutputRecord{
+			Original: original,
+			URL:      injection.Result,
+			Position: injection.Position,
+			Name:     injection.Name,
+			Index:    injection.Index,
+			Payload:  payload,
+			Headers:  injection.Headers,
+		}))
+	case OutputNuclei:
+		record := NucleiRecord{}
+		record.Request.Method = "GET"
+		record.Request.Endpoint = injection.Result
+		record.Request.Raw = rawGetRequest(injection.Result, injection.Headers)
+		fmt.Println(toJSON(record))
+	case OutputRaw:
+		PrintRawRequest(rawGetRequest(injection.Result, injection.Headers))
+	case OutputFFUF:
+		if len(injection.Headers) > 0 {
+			return
+		}
+		if seenTemplates[injection.Result] {
+			return
+		}
+		seenTemplates[injection.Result] = true
+		fmt.Println(injection.Result)
+	default:
+		// can't put headers in a URL
+		if len(injection.Headers) > 0 {
+			return
+		}
+		if showTag {
+			fmt.Printf("[%s] %s\n", injection.Position, injection.Result)
+			return
+		}
+		fmt.Println(injection.Result)
+	}
+}
+
+func toJSON(v interface{}) string {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	// keep URLs as it is
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// escapeRequestTarget percent-encode bytes that are not allowed in the request line like space or quote
+// the existing %XX sequences are kept so the encoded payloads don't get encoded twice
+func escapeRequestTarget(target string) string {
+	var b strings.Builder
+	for i := 0; i < len(target); i++ {
+		c := target[i]
+		switch {
+		case c == '%' && i+2 < len(target) && isHex(target[i+1]) && isHex(target[i+2]):
+			b.WriteByte(c)
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			b.WriteByte(c)
+		case strings.IndexByte("-._~!$&'()*+,;=:@/?", c) >= 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// rawGetRequest build a simple GET request from URL
+func rawGetRequest(raw string, headers []string) string {
+	r := SplitRawURL(raw)
+	host := r.Prefix
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	target := r.Path
+	if target == "" {
+		target = "/"
+	}
+	if r.HasQuery {
+		target += "?" + r.Query
+	}
+	lines := []string{fmt.Sprintf("GET %s HTTP/1.1", escapeRequestTarget(target)), "Host: " + host, "Accept: */*"}
+	lines = append(lines, headers...)
+	return strings.Join(lines, "\r\n") + "\r\n\r\n"
+}
+
+// number of raw requests written to -od
+var rawCount int
+
+// PrintRawRequest print raw request as base64 or write it to a file in -od folder
+func PrintRawRequest(raw string) {
+	if outputDir == "" {
+		fmt.Println(base64.StdEncoding.EncodeToString([]byte(raw)))
+		return
+	}
+	rawCount++
+	filename := filepath.Join(outputDir, fmt.Sprintf("request-%d.txt", rawCount))
+	if err := os.WriteFile(filename, []byte(raw), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write %s [%s]\n", filename, err)
+		return
+	}
+	fmt.Println(filename)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	path2 "path"
+	"strings"
+)
+
+// Extra positions of URL
+const (
+	PositionPath      = "path"
+	PositionDuplicate = "dup"
+	PositionArray     = "array"
+	PositionFilename  = "filename"
+	PositionMatrix    = "matrix"
+	PositionFragment  = "fragment"
+	PositionUserinfo  = "userinfo"
+	PositionParamName = "param-name"
+)
+
+// AllPositions is the order when using -pos all
+var AllPositions = []string{PositionQuery, PositionPath, PositionDuplicate, PositionArray, PositionFilename, PositionMatrix, PositionFragment, PositionUserinfo, PositionParamName}
+
+// ParsePositions parse positions in format: query,dup,filename
+func ParsePositions(raw string) ([]string, error) {
+	var positions []string
+	for _, position := range strings.Split(raw, ",") {
+		position = strings.TrimSpace(position)
+		if position == "" {
+			continue
+		}
+		if position == "all" {
+			return AllPositions, nil
+		}
+
+		valid := false
+		for _, p := range AllPositions {
+			if p == position {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown position: %s", position)
+		}
+		positions = append(positions, position)
+	}
+	return positions, nil
+}
+
+// ExtraBuilder inject payload to the extra position, the rest of URL is kept as it is
+func ExtraBuilder(urlString string, payload string, position string) []Injection {
+	var results []Injection
+	r := SplitRawURL(urlString)
+	var pairs []string
+	if r.Query != "" {
+		pairs = strings.Split(r.Query, "&")
+	}
+
+	switch position {
+	case PositionDuplicate:
+		// a=1&a=PAYLOAD, once per name even when it's repeated
+		seen := make(map[string]bool)
+		for i, pair := range pairs {
+			name := strings.SplitN(pair, "=", 2)[0]
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			clone := r
+			clone.Query = r.Query + "&" + name + "=" + payload
+			results = append(results, Injection{Position: position, Name: name, Index: i, Result: clone.String()})
+		}
+	case PositionParamName:
+		// PAYLOAD=1
+		for i, pair := range pairs {
+			kv := strings.SplitN(pair, "=", 2)
+			clonePairs := append(pairs[:0:0], pairs...)
+			clonePairs[i] = injectValue(kv[0], payload)
+			if len(kv) == 2 {
+				clonePairs[i] += "=" + kv[1]
+			}
+			clone := r
+			clone.Query = strings.Join(clonePairs, "&")
+			results = append(results, Injection{Position: position, Name: kv[0], Index: i, Result: clone.String()})
+		}
+	case PositionArray:
+		// a[]=PAYLOAD
+		for i, pair := range pairs {
+			name := strings.SplitN(pair, "=", 2)[0]
+			clonePairs := append(pairs[:0:0], pairs...)
+			clonePairs[i] = strings.TrimSuffix(name, "[]") + "[]=" + payload
+			clone := r
+			clone.Query = strings.Join(clonePairs, "&")
+			results = append(results, Injection{Position: position, Name: name, Index: i, Result: clone.String()})
+		}
+	case PositionFilename:
+		// /file.PAYLOAD.php
+		dir, file := path2.Split(r.Path)
+		ext := path2.Ext(file)
+		if ext == "" || ext == file {
+			return results
+		}
+		clone := r
+		clone.Path = dir + strings.TrimSuffix(file, ext) + "." + payload + ext
+		results = append(results, Injection{Position: position, Name: file, Result: clone.String()})
+	case PositionMatrix:
+		// /path;a=PAYLOAD
+		basePath := r.Path
+		if basePath == "" {
+			basePath = "/"
+		}
+		if len(pairs) == 0 {
+			clone := r
+			clone.Path = basePath + ";" + payload
+			results = append(results, Injection{Position: position, Result: clone.String()})
+		}
+		seen := make(map[string]bool)
+		for i, pair := range pairs {
+			name := strings.SplitN(pair, "=", 2)[0]
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			clone := r
+			clone.Path = basePath + ";" + name + "=" + payload
+			results = append(results, Injection{Position: position, Name: name, Index: i, Result: clone.String()})
+		}
+	case PositionFragment:
+		clone := r
+		clone.Fragment = "#" + payload
+		results = append(results, Injection{Position: position, Result: clone.String()})
+	case PositionUserinfo:
+		i := strings.Index(r.Prefix, "://")
+		if i == -1 {
+			return results
+		}
+		host := r.Prefix[i+3:]
+		if at := strings.LastIndex(host, "@"); at != -1 {
+			host = host[at+1:]
+		}
+		clone := r
+		clone.Prefix = r.Prefix[:i+3] + payload + "@" + host
+		results = append(results, Injection{Position: position, Result: clone.String()})
+	}
+	return results
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtraBuilderRepeatedNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		position string
+		want     []string
+	}{
+		{
+			"dup once per name",
+			"https://a.com/p?a=1&b=2&a=3",
+			PositionDuplicate,
+			[]string{"https://a.com/p?a=1&b=2&a=3&a=X", "https://a.com/p?a=1&b=2&a=3&b=X"},
+		},
+		{
+			"matrix once per name",
+			"https://a.com/p?a=1&a=3",
+			PositionMatrix,
+			[]string{"https://a.com/p;a=X?a=1&a=3"},
+		},
+		{
+			"matrix without query",
+			"https://a.com",
+			PositionMatrix,
+			[]string{"https://a.com/;X"},
+		},
+		{
+			"array keep every occurrence",
+			"https://a.com/?a=1&a=3",
+			PositionArray,
+			[]string{"https://a.com/?a[]=X&a=3", "https://a.com/?a=1&a[]=X"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, inj := range ExtraBuilder(tt.raw, "X", tt.position) {
+				got = append(got, inj.Result)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtraBuilder(%q, %s) = %v, want %v", tt.raw, tt.position, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Positions of raw request
+const (
+	PositionQuery     = "query"
+	PositionBody      = "body"
+	PositionJSON      = "json"
+	PositionMultipart = "multipart"
+	PositionHeader    = "header"
+	PositionCookie    = "cookie"
+)
+
+// headers that shouldn't be touched
+var skipHeaders = []string{"host", "content-length", "content-type", "cookie", "connection"}
+
+var boundaryRE = regexp.MustCompile(`(?i)boundary="?([^";]+)"?`)
+
+// RawRequest is a HTTP request kept byte by byte
+type RawRequest struct {
+	RequestLine string
+	Headers     []string
+	Body        string
+	newline     string
+}
+
+// Injection is a generated request/URL with where the payload was injected
+type Injection struct {
+	Position string
+	// Name is the param name or the original path segment
+	Name   string
+	Index  int
+	Result string
+	// Headers are extra headers that only work with raw output
+	Headers []string
+}
+
+// ParseRawRequest parse Burp style raw request
+func ParseRawRequest(raw string) (*RawRequest, error) {
+	newline := "\r\n"
+	if !strings.Contains(raw, "\r\n") {
+		newline = "\n"
+	}
+
+	head, body := raw, ""
+	if i := strings.Index(raw, newline+newline); i != -1 {
+		head = raw[:i]
+		body = raw[i+len(newline)*2:]
+	}
+
+	lines := strings.Split(head, newline)
+	if len(strings.Fields(lines[0])) < 2 {
+		return nil, fmt.Errorf("invalid request line: %s", lines[0])
+	}
+
+	return &RawRequest{
+		RequestLine: lines[0],
+		Headers:     lines[1:],
+		Body:        body,
+		newline:     newline,
+	}, nil
+}
+
+// Clone copy the request
+func (r *RawRequest) Clone() *RawRequest {
+	clone := *r
+	clone.Headers = append(r.Headers[:0:0], r.Headers...)
+	return &clone
+}
+
+// GetHeader get header value by name
+func (r *RawRequest) GetHeader(name string) (int, string) {
+	for i, h := range r.Headers {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), name) {
+			return i, strings.TrimSpace(parts[1])
+		}
+	}
+	return -1, ""
+}
+
+// String rebuild the request and update Content-Length if it exists
+func (r *RawRequest) String() string {
+	headers := append(r.Headers[:0:0], r.Headers...)
+	for i, h := range headers {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), "content-length") {
+			headers[i] = fmt.Sprintf("%s: %d", parts[0], len(r.Body))
+		}
+	}
+	lines := append([]string{r.RequestLine}, headers...)
+	return strings.Join(lines, r.newline) + r.newline + r.newline + r.Body
+}
+
+// RequestBuilder inject payload to every position of the request one by one
+func RequestBuilder(req *RawRequest, payload string, positions map[string]bool) []Injection {
+	var results []Injection
+	if positions[PositionQuery] {
+		results = append(results, injectRequestQuery(req, payload)...)
+	}
+	if positions[PositionHeader] {
+		results = append(results, injectHeaders(req, payload)...)
+	}
+	if positions[PositionCookie] {
+		results = append(results, injectCookies(req, payload)...)
+	}
+
+	_, contentType := req.GetHeader("Content-Type")
+	contentType = strings.ToLower(contentType)
+	switch {
+	case strings.Contains(contentType, "json") || (contentType == "" && gjson.Valid(req.Body) && strings.HasPrefix(strings.TrimSpace(req.Body), "{")):
+		if positions[PositionJSON] {
+			results = append(results, injectJSON(req, payload)...)
+		}
+	case strings.Contains(contentType, "multipart/form-data"):
+		if positions[PositionMultipart] {
+			results = append(results, injectMultipart(req, payload)...)
+		}
+	default:
+		if positions[PositionBody] && req.Body != "" {
+			for _, injection := range injectKeyValues(req.Body, "&", payload) {
+				clone := req.Clone()
+				clone.Body = injection.Result
+				results = append(results, Injection{Position: PositionBody, Name: injection.Name, Result: clone.String()})
+			}
+		}
+	}
+	return results
+}
+
+func injectValue(value string, payload string) string {
+	if appendMode {
+		return value + payload
+	}
+	return payload
+}
+
+// injectKeyValues replace value of each key=value pair one by one, everything else is kept as it is
+func injectKeyValues(raw string, sep string, payload string) []Injection {
+	var results []Injection
+	pairs := strings.Split(raw, sep)
+	for i, pair := range pairs {
+		key, value := pair, ""
+		hasValue := strings.Contains(pair, "=")
+		if hasValue {
+			kv := strings.SplitN(pair, "=", 2)
+			key, value = kv[0], kv[1]
+		}
+		if strings.TrimSpace(key) == "" {
+			continue
+		}
+
+		clone := append(pairs[:0:0], pairs...)
+		clone[i] = key + "=" + injectValue(value, payload)
+		results = append(results, Injection{Name: strings.TrimSpace(key), Result: strings.Join(clone, sep)})
+	}
+	return results
+}
+
+func injectRequestQuery(req *RawRequest, payload string) []Injection {
+	var results []Injection
+	parts := strings.Split(req.RequestLine, " ")
+	if len(parts) < 2 {
+		return results
+	}
+	target := parts[1]
+	i := strings.Index(target, "?")
+	if i == -1 {
+		return results
+	}
+
+	for _, injection := range injectKeyValues(target[i+1:], "&", payload) {
+		clone := req.Clone()
+		newParts := append(parts[:0:0], parts...)
+		newParts[1] = target[:i+1] + injection.Result
+		clone.RequestLine = strings.Join(newParts, " ")
+		results = append(results, Injection{Position: PositionQuery, Name: injection.Name, Result: clone.String()})
+	}
+	return results
+}
+
+func injectHeaders(req *RawRequest, payload string) []Injection {
+	var results []Injection
+	for i, h := range req.Headers {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.TrimSpace(parts[0])
+		if isSkipHeader(name) {
+			continue
+		}
+
+		clone := req.Clone()
+		clone.Headers[i] = fmt.Sprintf("%s: %s", parts[0], injectValue(strings.TrimSpace(parts[1]), payload))
+		results = append(results, Injection{Position: PositionHeader, Name: name, Result: clone.String()})
+	}
+	return results
+}
+
+func isSkipHeader(name string) bool {
+	for _, h := range skipHeaders {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func injectCookies(req *RawRequest, payload string) []Injection {
+	var results []Injection
+	i, cookie := req.GetHeader("Cookie")
+	if i == -1 || cookie == "" {
+		return results
+	}
+	name := strings.SplitN(req.Headers[i], ":", 2)[0]
+
+	for _, injection := range injectKeyValues(cookie, "; ", payload) {
+		clone := req.Clone()
+		clone.Headers[i] = fmt.Sprintf("%s: %s", name, injection.Result)
+		results = append(results, Injection{Position: PositionCookie, Name: injection.Name, Result: clone.String()})
+	}
+	return results
+}
+
+func injectJSON(req *RawRequest, payload string) []Injection {
+	var results []Injection
+	if !gjson.Valid(req.Body) {
+		return results
+	}
+
+	var paths []string
+	jsonLeafPaths(gjson.Parse(req.Body), "", &paths)
+	for _, p := range paths {
+		value := gjson.Get(req.Body, p).String()
+		body, err := sjson.Set(req.Body, p, injectValue(value, payload))
+		if err != nil {
+			continue
+		}
+		clone := req.Clone()
+		clone.Body = body
+		results = append(results, Injection{Position: PositionJSON, Name: p, Result: clone.String()})
+	}
+	return results
+}
+
+// jsonLeafPaths collect path of every leaf value, e.g: user.address.0.city
+func jsonLeafPaths(value gjson.Result, prefix string, paths *[]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch {
+	case value.IsObject():
+		value.ForEach(func(key, v gjson.Result) bool {
+			jsonLeafPaths(v, join(escapeJSONKey(key.String())), paths)
+			return true
+		})
+	case value.IsArray():
+		i := 0
+		value.ForEach(func(_, v gjson.Result) bool {
+			jsonLeafPaths(v, join(strconv.Itoa(i)), paths)
+			i++
+			return true
+		})
+	default:
+		if prefix != "" {
+			*paths = append(*paths, prefix)
+		}
+	}
+}
+
+func escapeJSONKey(key string) string {
+	var b strings.Builder
+	for _, c := range key {
+		if strings.ContainsRune(`.*?|#@\!=<>%`, c) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// injectMultipart replace value of each form field, file parts are skipped
+func injectMultipart(req *RawRequest, payload string) []Injection {
+	var results []Injection
+	_, rawContentType := req.GetHeader("Content-Type")
+	m := boundaryRE.FindStringSubmatch(rawContentType)
+	if len(m) < 2 {
+		return results
+	}
+	delimiter := "--" + m[1]
+	parts := strings.Split(req.Body, delimiter)
+
+	for i, part := range parts {
+		headerEnd := strings.Index(part, req.newline+req.newline)
+		if headerEnd == -1 {
+			continue
+		}
+		header := part[:headerEnd]
+		if !strings.Contains(strings.ToLower(header), "content-disposition") || strings.Contains(header, "filename=") {
+			continue
+		}
+		name := multipartName(header)
+
+		valueStart := headerEnd + len(req.newline)*2
+		valueEnd := len(part)
+		if strings.HasSuffix(part, req.newline) {
+			valueEnd -= len(req.newline)
+		}
+		value := part[valueStart:valueEnd]
+
+		clone := append(parts[:0:0], parts...)
+		clone[i] = part[:valueStart] + injectValue(value, payload) + part[valueEnd:]
+		newReq := req.Clone()
+		newReq.Body = strings.Join(clone, delimiter)
+		results = append(results, Injection{Position: PositionMultipart, Name: name, Result: newReq.String()})
+	}
+	return results
+}
+
+var multipartNameRE = regexp.MustCompile(`name="([^"]*)"`)
+
+func multipartName(header string) string {
+	m := multipartNameRE.FindStringSubmatch(header)
+	if len(m) < 2 {
+		return ""
+	}
+	return m[1]
+}
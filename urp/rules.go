@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule pick payloads for the injection points that matched
+//
+//	rules:
+//	  - name: ssrf
+//	    param: '(?i)url|redirect|next|callback'
+//	    file: ssrf.txt
+//	  - name: sqli
+//	    value: '^[0-9]+$'
+//	    payloads: ["'", "1 OR 1=1"]
+//	default: ["FUZZ"]
+type Rule struct {
+	Name string `yaml:"name"`
+	// Param is regex of param name
+	Param string `yaml:"param"`
+	// Value is regex of the original value
+	Value string `yaml:"value"`
+	// Positions limit the rule to some positions, e.g: query, path
+	Positions []string `yaml:"positions"`
+	Payloads  []string `yaml:"payloads"`
+	// File is payload file, relative to the rules file
+	File string `yaml:"file"`
+
+	paramRE *regexp.Regexp
+	valueRE *regexp.Regexp
+}
+
+// RuleSet is the content of rules file
+type RuleSet struct {
+	Rules []*Rule `yaml:"rules"`
+	// Default payloads when no rule matched, fallback to -I/-iL
+	Default []string `yaml:"default"`
+}
+
+// LoadRules load rules from YAML file
+func LoadRules(filename string) (*RuleSet, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	rs := &RuleSet{}
+	if err := yaml.Unmarshal(content, rs); err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rs.Rules {
+		if rule.Param != "" {
+			if rule.paramRE, err = regexp.Compile(rule.Param); err != nil {
+				return nil, fmt.Errorf("rule %s: %s", rule.Name, err)
+			}
+		}
+		if rule.Value != "" {
+			if rule.valueRE, err = regexp.Compile(rule.Value); err != nil {
+				return nil, fmt.Errorf("rule %s: %s", rule.Name, err)
+			}
+		}
+		if rule.File != "" {
+			payloadFile := rule.File
+			if !filepath.IsAbs(payloadFile) {
+				payloadFile = filepath.Join(filepath.Dir(filename), payloadFile)
+			}
+			payloads := ReadingLines(payloadFile)
+			if len(payloads) == 0 {
+				return nil, fmt.Errorf("rule %s: no payload in %s", rule.Name, payloadFile)
+			}
+			rule.Payloads = append(rule.Payloads, payloads...)
+		}
+	}
+	return rs, nil
+}
+
+// Match check if the injection point match the rule
+func (r *Rule) Match(position string, name string, value string) bool {
+	if len(r.Positions) > 0 {
+		found := false
+		for _, p := range r.Positions {
+			if p == position {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.paramRE != nil && !r.paramRE.MatchString(name) {
+		return false
+	}
+	if r.valueRE != nil && !r.valueRE.MatchString(value) {
+		return false
+	}
+	return true
+}
+
+// Select return payloads of every matched rule
+func (rs *RuleSet) Select(position string, name string, value string) []string {
+	var payloads []string
+	seen := make(map[string]bool)
+	for _, rule := range rs.Rules {
+		if !rule.Match(position, name, value) {
+			continue
+		}
+		for _, p := range rule.Payloads {
+			if !seen[p] {
+				seen[p] = true
+				payloads = append(payloads, p)
+			}
+		}
+	}
+
+	if len(payloads) > 0 {
+		return payloads
+	}
+	if len(rs.Default) > 0 {
+		return rs.Default
+	}
+	return payloadList
+}